@@ -0,0 +1,168 @@
+package proci
+
+import (
+	"sort"
+	"strings"
+)
+
+// Container identifies the container that a process runs in.
+type Container struct {
+	ID      string // Full container ID, 64 hexadecimal characters
+	Runtime string // "docker", "containerd", "cri-o", "podman" or "kubernetes", empty if not known
+}
+
+// Prefixes used by the container runtimes for the cgroup of a container
+// when the cgroups are managed by systemd, such as "docker-<id>.scope".
+var containerScopePrefixes = []struct {
+	prefix  string
+	runtime string
+}{
+	{"docker-", "docker"},
+	{"cri-containerd-", "containerd"},
+	{"crio-", "cri-o"},
+	{"libpod-", "podman"},
+}
+
+// DetectContainer finds the container that a process runs in from its
+// cgroups, see GetProcessCgroups. It recognizes the cgroup paths used by
+// docker, containerd, cri-o, podman and Kubernetes. Returns nil if the
+// process does not run in a container.
+func DetectContainer(cgroups []Cgroup) *Container {
+	for _, cgroup := range cgroups {
+		elements := strings.Split(cgroup.Path, "/")
+		for i := len(elements) - 1; i >= 0; i-- {
+			if container := containerFromElement(elements[i]); container != nil {
+				if container.Runtime == "" {
+					container.Runtime = containerRuntimeFromPath(elements[:i])
+				}
+				return container
+			}
+		}
+	}
+	return nil
+}
+
+// Returns the container if the cgroup path element is a container ID, with
+// or without a runtime prefix.
+func containerFromElement(element string) *Container {
+	element = strings.TrimSuffix(element, ".scope")
+	if strings.HasPrefix(element, "libpod-conmon-") {
+		// The container monitor of podman, not the container itself
+		return nil
+	}
+	runtime := ""
+	for _, scope := range containerScopePrefixes {
+		if strings.HasPrefix(element, scope.prefix) {
+			element = strings.TrimPrefix(element, scope.prefix)
+			runtime = scope.runtime
+			break
+		}
+	}
+	if !isContainerID(element) {
+		return nil
+	}
+	return &Container{ID: element, Runtime: runtime}
+}
+
+// Returns the runtime from the parent elements of a plain container ID in
+// a cgroup path, such as "/docker/<id>". Empty if not known.
+func containerRuntimeFromPath(parents []string) string {
+	for i := len(parents) - 1; i >= 0; i-- {
+		switch {
+		case parents[i] == "docker":
+			return "docker"
+		case parents[i] == "libpod_parent":
+			return "podman"
+		case strings.HasPrefix(parents[i], "kubepods"):
+			return "kubernetes"
+		}
+	}
+	return ""
+}
+
+// Returns true if s is 64 lowercase hexadecimal characters.
+func isContainerID(s string) bool {
+	if len(s) != 64 {
+		return false
+	}
+	for _, c := range s {
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+// CgroupMemoryUsage is the memory used by all processes in a cgroup.
+type CgroupMemoryUsage struct {
+	Path        string     // The cgroup path, see MemoryUsageByCgroup
+	Container   *Container // nil if the cgroup is not a container
+	MemoryUsage uint64     // Sum of the memory usage of the processes
+	Pids        []uint32   // The processes in the cgroup, sorted by PID
+}
+
+// MemoryUsageByCgroup sums the memory usage of the processes in a snapshot
+// per cgroup. The path of the cgroup v1 memory controller is used if the
+// process has one, otherwise the path of the cgroup v2 unified hierarchy.
+// On hybrid hosts the unified hierarchy has no controllers, so processes in
+// different containers can share the same unified path.
+// Processes whose cgroups or memory usage could not be read are not
+// included. The result is sorted by memory usage, highest first.
+func MemoryUsageByCgroup(snapshot *SystemSnapshot) []CgroupMemoryUsage {
+	usages := make(map[string]*CgroupMemoryUsage)
+	var paths []string
+	for _, process := range snapshot.Processes {
+		if process.CgroupsErr != nil || process.MemoryUsageErr != nil {
+			continue
+		}
+		path, found := cgroupPath(process.Cgroups)
+		if !found {
+			continue
+		}
+		usage := usages[path]
+		if usage == nil {
+			usage = &CgroupMemoryUsage{
+				Path:      path,
+				Container: DetectContainer(process.Cgroups)}
+			usages[path] = usage
+			paths = append(paths, path)
+		}
+		usage.MemoryUsage += process.MemoryUsage
+		usage.Pids = append(usage.Pids, process.Pid)
+	}
+
+	result := make([]CgroupMemoryUsage, 0, len(usages))
+	for _, path := range paths {
+		result = append(result, *usages[path])
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		if result[i].MemoryUsage != result[j].MemoryUsage {
+			return result[i].MemoryUsage > result[j].MemoryUsage
+		}
+		return result[i].Path < result[j].Path
+	})
+	return result
+}
+
+// Returns the path of the cgroup in the cgroup v2 unified hierarchy.
+func unifiedCgroupPath(cgroups []Cgroup) (string, bool) {
+	for _, cgroup := range cgroups {
+		if cgroup.HierarchyID == 0 {
+			return cgroup.Path, true
+		}
+	}
+	return "", false
+}
+
+// Returns the path used to group processes by cgroup, see
+// MemoryUsageByCgroup.
+func cgroupPath(cgroups []Cgroup) (string, bool) {
+	for _, cgroup := range cgroups {
+		for _, controller := range cgroup.Controllers {
+			if controller == "memory" {
+				return cgroup.Path, true
+			}
+		}
+	}
+	return unifiedCgroupPath(cgroups)
+}
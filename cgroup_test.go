@@ -0,0 +1,107 @@
+// proci cgroup and container unit tests
+package proci
+
+import (
+	"reflect"
+	"testing"
+)
+
+const testContainerID = "3f4e8a6c2b1d9e7f5a3c1b0d8e6f4a2c9b7d5e3f1a0c8b6d4e2f0a9c7b5d3e1f"
+
+func TestDetectContainer(t *testing.T) {
+	tests := []struct {
+		path    string
+		runtime string // Empty if no container is expected
+	}{
+		{"/docker/" + testContainerID, "docker"},
+		{"/system.slice/docker-" + testContainerID + ".scope", "docker"},
+		{"/system.slice/containerd.service/cri-containerd-" + testContainerID + ".scope", "containerd"},
+		{"/kubepods/burstable/pod0a1b2c3d-4e5f-6789-abcd-ef0123456789/" + testContainerID, "kubernetes"},
+		{"/kubepods.slice/kubepods-besteffort.slice/kubepods-besteffort-pod0a1b.slice/crio-" + testContainerID + ".scope", "cri-o"},
+		{"/machine.slice/libpod-" + testContainerID + ".scope/container", "podman"},
+		{"/libpod_parent/" + testContainerID, "podman"},
+		{"/machine.slice/libpod-conmon-" + testContainerID + ".scope", ""},
+		{"/user.slice/user-1000.slice/session-2.scope", ""},
+		{"/", ""},
+	}
+	for _, test := range tests {
+		container := DetectContainer([]Cgroup{{Path: test.path}})
+		if test.runtime == "" {
+			if container != nil {
+				t.Errorf("Expected no container for %q but it was %+v", test.path, container)
+			}
+			continue
+		}
+		if container == nil || container.ID != testContainerID || container.Runtime != test.runtime {
+			t.Errorf("Expected %s container for %q but it was %+v", test.runtime, test.path, container)
+		}
+	}
+
+	// Plain container ID without known runtime
+	container := DetectContainer([]Cgroup{{Path: "/default/" + testContainerID}})
+	if container == nil || container.Runtime != "" {
+		t.Errorf("Expected container without runtime but it was %+v", container)
+	}
+}
+
+func TestMemoryUsageByCgroup(t *testing.T) {
+	pm := GenerateMock(5)
+	containerCgroups := []Cgroup{{HierarchyID: 0, Path: "/system.slice/docker-" + testContainerID + ".scope"}}
+	pm.Processes[3].Cgroups = containerCgroups
+	pm.Processes[4].Cgroups = containerCgroups
+	pm.Processes[1].Cgroups = []Cgroup{{HierarchyID: 3, Controllers: []string{"memory"}, Path: "/v1"}}
+	pm.Processes[2].DoFailCgroups = true
+
+	snapshot, err := pm.Snapshot()
+	if err != nil {
+		t.Fatalf("Snapshot returned error: %s", err)
+	}
+	usages := MemoryUsageByCgroup(snapshot)
+	if len(usages) != 3 {
+		t.Fatalf("Expected 3 cgroups but it was %d", len(usages))
+	}
+	// Memory usage in the mock is 1024 + 1024 * PID
+	if usages[0].MemoryUsage != 4096+5120 || !reflect.DeepEqual(usages[0].Pids, []uint32{3, 4}) {
+		t.Errorf("Unexpected container usage %+v", usages[0])
+	}
+	if usages[0].Container == nil || usages[0].Container.ID != testContainerID {
+		t.Errorf("Expected container %s but it was %+v", testContainerID, usages[0].Container)
+	}
+	if usages[1].Path != "/v1" || usages[1].MemoryUsage != 2048 {
+		t.Errorf("Unexpected cgroup v1 usage %+v", usages[1])
+	}
+	if usages[2].Path != "/user.slice" || usages[2].MemoryUsage != 1024 {
+		t.Errorf("Unexpected default usage %+v", usages[2])
+	}
+}
+
+func TestMockCgroupStats(t *testing.T) {
+	pm := GenerateMock(3)
+	pm.Processes[2].Cgroups = []Cgroup{{HierarchyID: 1, Controllers: []string{"memory"}, Path: "/v1"}}
+	stats, err := pm.CgroupStats("/user.slice")
+	if err != nil {
+		t.Fatalf("Expected no error for CgroupStats but it was %s", err)
+	}
+	if stats.MemoryCurrent != 3*4096 {
+		t.Errorf("Unexpected cgroup stats %+v", stats)
+	}
+	if _, err = pm.CgroupStats("/nonexisting"); err == nil {
+		t.Error("Expected error for non existing cgroup")
+	}
+
+	snapshot, err := pm.Snapshot()
+	if err != nil {
+		t.Fatalf("Snapshot returned error: %s", err)
+	}
+	if snapshot.Processes[0].CgroupStats != stats || snapshot.Processes[1].CgroupStats != stats {
+		t.Error("Expected processes in the same cgroup to share the cgroup stats")
+	}
+	if snapshot.Processes[2].CgroupStats != nil || snapshot.Processes[2].CgroupStatsErr != nil {
+		t.Error("Expected no cgroup stats for process without cgroup v2")
+	}
+
+	pm.DoFailCgroupStats = true
+	if snapshot, _ = pm.Snapshot(); snapshot.Processes[0].CgroupStatsErr == nil {
+		t.Error("Expected CgroupStatsErr to be set")
+	}
+}
@@ -0,0 +1,94 @@
+package proci
+
+import (
+	"strings"
+)
+
+// splitCommandLine splits a Windows command line into arguments using the
+// same rules as CommandLineToArgvW:
+//
+//   - The first argument is the program name. It ends at the first space or
+//     tab, unless it starts with a double quote in which case it ends at the
+//     next double quote. Backslashes have no special meaning in it.
+//   - The other arguments are separated by spaces and tabs, except within
+//     double quotes.
+//   - 2n backslashes followed by a double quote produce n backslashes and
+//     the double quote starts or ends a quoted part.
+//   - 2n+1 backslashes followed by a double quote produce n backslashes and
+//     a literal double quote.
+//   - Two double quotes within a quoted part produce a literal double quote
+//     and end the quoted part.
+//   - Backslashes not followed by a double quote are literal.
+func splitCommandLine(commandLine string) []string {
+	if commandLine == "" {
+		return []string{}
+	}
+
+	// The program name
+	var program string
+	if commandLine[0] == '"' {
+		end := strings.IndexByte(commandLine[1:], '"')
+		if end < 0 {
+			return []string{commandLine[1:]}
+		}
+		program = commandLine[1 : end+1]
+		commandLine = commandLine[end+2:]
+	} else {
+		end := strings.IndexAny(commandLine, " \t")
+		if end < 0 {
+			return []string{commandLine}
+		}
+		program = commandLine[:end]
+		commandLine = commandLine[end:]
+	}
+	args := []string{program}
+
+	// The other arguments
+	for {
+		commandLine = strings.TrimLeft(commandLine, " \t")
+		if commandLine == "" {
+			return args
+		}
+		var arg string
+		arg, commandLine = nextArg(commandLine)
+		args = append(args, arg)
+	}
+}
+
+// nextArg reads one argument from the start of commandLine, which must not
+// start with a space or tab. Returns the argument and the rest of the command
+// line.
+func nextArg(commandLine string) (string, string) {
+	var arg strings.Builder
+	inQuote := false
+	backslashes := 0
+	for i := 0; i < len(commandLine); i++ {
+		c := commandLine[i]
+		switch {
+		case c == '\\':
+			backslashes++
+			continue
+		case c == '"':
+			arg.WriteString(strings.Repeat(`\`, backslashes/2))
+			if backslashes%2 == 1 {
+				arg.WriteByte('"')
+			} else if inQuote && i+1 < len(commandLine) && commandLine[i+1] == '"' {
+				arg.WriteByte('"')
+				inQuote = false
+				i++
+			} else {
+				inQuote = !inQuote
+			}
+			backslashes = 0
+			continue
+		case (c == ' ' || c == '\t') && !inQuote:
+			arg.WriteString(strings.Repeat(`\`, backslashes))
+			return arg.String(), commandLine[i:]
+		}
+		arg.WriteString(strings.Repeat(`\`, backslashes))
+		backslashes = 0
+		arg.WriteByte(c)
+	}
+	arg.WriteString(strings.Repeat(`\`, backslashes))
+	return arg.String(), ""
+}
@@ -0,0 +1,35 @@
+// proci command line parsing unit tests
+package proci
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitCommandLine(t *testing.T) {
+	tests := []struct {
+		commandLine string
+		expected    []string
+	}{
+		{``, []string{}},
+		{`program`, []string{`program`}},
+		{`program a b`, []string{`program`, `a`, `b`}},
+		{`"C:\Program Files\app.exe" a`, []string{`C:\Program Files\app.exe`, `a`}},
+		{`C:\dir\app.exe "a b" c`, []string{`C:\dir\app.exe`, `a b`, `c`}},
+		{`p  a	 b `, []string{`p`, `a`, `b`}},
+		{`p a\b c\\`, []string{`p`, `a\b`, `c\\`}},
+		{`p a\"b`, []string{`p`, `a"b`}},
+		{`p a\\"b c"`, []string{`p`, `a\b c`}},
+		{`p a\\\"b`, []string{`p`, `a\"b`}},
+		{`p "a"" b`, []string{`p`, `a"`, `b`}},
+		{`p "" x`, []string{`p`, ``, `x`}},
+		{`p "a b`, []string{`p`, `a b`}},
+		{`"p a`, []string{`p a`}},
+	}
+	for _, test := range tests {
+		args := splitCommandLine(test.commandLine)
+		if !reflect.DeepEqual(args, test.expected) {
+			t.Errorf("splitCommandLine(%q) = %q, expected %q", test.commandLine, args, test.expected)
+		}
+	}
+}
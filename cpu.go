@@ -0,0 +1,35 @@
+package proci
+
+// CPUSampler calculates the CPU usage of processes in percent, based on how
+// much CPU time they have used between two samples.
+//
+// A CPUSampler is not safe for concurrent use.
+type CPUSampler struct {
+	sampler[CPUTimes]
+}
+
+// NewCPUSampler creates a CPUSampler that reads the CPU times using the
+// provided Interface implementation.
+func NewCPUSampler(p Interface) *CPUSampler {
+	return &CPUSampler{newSampler(p.GetProcessCPUTimes)}
+}
+
+// Sample returns the CPU usage of the process since the previous call to
+// Sample for the same process. 100 percent corresponds to one fully used
+// CPU core, so processes with several threads can use more than 100 percent.
+//
+// The first call for a process returns 0 since there is nothing to compare
+// with. If the process cannot be read it is forgotten and the error is
+// returned.
+func (c *CPUSampler) Sample(pid uint32) (float64, error) {
+	previous, current, elapsed, ok, err := c.next(pid)
+	if err != nil || !ok {
+		return 0, err
+	}
+	used := current.Total() - previous.Total()
+	if used < 0 {
+		// Used time decreased, i.e. the PID has been reused
+		return 0, nil
+	}
+	return float64(used) / float64(elapsed) * 100, nil
+}
@@ -0,0 +1,54 @@
+// proci CPU sampler unit tests
+package proci
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCPUSampler(t *testing.T) {
+	pm := GenerateMock(2)
+	pm.Processes[1].CPUTimes = []CPUTimes{
+		{User: 1 * time.Second, System: 1 * time.Second},
+		{User: 2 * time.Second, System: 1500 * time.Millisecond},
+		{User: 2 * time.Second, System: 1500 * time.Millisecond},
+		{User: 1 * time.Second, System: 0}} // PID reused
+
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	sampler := NewCPUSampler(pm)
+	sampler.now = func() time.Time { return now }
+
+	percent, err := sampler.Sample(1)
+	if err != nil {
+		t.Fatalf("Sample returned error: %s", err)
+	}
+	if percent != 0 {
+		t.Errorf("Expected 0 percent for first sample but it was %f", percent)
+	}
+
+	now = now.Add(10 * time.Second)
+	percent, _ = sampler.Sample(1)
+	if percent != 15 {
+		t.Errorf("Expected 15 percent but it was %f", percent)
+	}
+
+	now = now.Add(10 * time.Second)
+	percent, _ = sampler.Sample(1)
+	if percent != 0 {
+		t.Errorf("Expected 0 percent for idle process but it was %f", percent)
+	}
+
+	now = now.Add(10 * time.Second)
+	percent, _ = sampler.Sample(1)
+	if percent != 0 {
+		t.Errorf("Expected 0 percent when CPU time decreased but it was %f", percent)
+	}
+
+	pm.Processes[1].DoFailCPUTimes = true
+	if _, err = sampler.Sample(1); err == nil {
+		t.Fatal("Expected error for Sample when GetProcessCPUTimes fails")
+	}
+	if _, hasPrevious := sampler.previous[1]; hasPrevious {
+		t.Error("Expected failed process to be forgotten")
+	}
+}
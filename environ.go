@@ -0,0 +1,23 @@
+package proci
+
+import (
+	"strings"
+)
+
+// parseEnviron converts "KEY=value" strings into a map. Strings without a
+// "=" are ignored. On Windows there are hidden variables starting with "=",
+// such as "=C:=C:\dir", so a "=" first in the string is part of the key.
+func parseEnviron(entries []string) map[string]string {
+	environ := make(map[string]string, len(entries))
+	for _, entry := range entries {
+		if entry == "" {
+			continue
+		}
+		separator := strings.IndexByte(entry[1:], '=') + 1
+		if separator == 0 {
+			continue
+		}
+		environ[entry[:separator]] = entry[separator+1:]
+	}
+	return environ
+}
@@ -0,0 +1,15 @@
+// proci environment parsing unit tests
+package proci
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseEnviron(t *testing.T) {
+	environ := parseEnviron([]string{"A=1", "B=x=y", "=C:=C:\\dir", "invalid", "", "E="})
+	expected := map[string]string{"A": "1", "B": "x=y", "=C:": "C:\\dir", "E": ""}
+	if !reflect.DeepEqual(environ, expected) {
+		t.Errorf("Expected environment %q but it was %q", expected, environ)
+	}
+}
@@ -0,0 +1,28 @@
+package proci
+
+// FileType is the type of the target of an open file descriptor.
+type FileType int
+
+const (
+	FileTypeUnknown   FileType = iota // The type could not be determined
+	FileTypeFile                      // A file, directory or device
+	FileTypeSocket                    // A network or Unix domain socket
+	FileTypePipe                      // A pipe or FIFO
+	FileTypeAnonInode                 // A file without an inode, such as an eventfd
+)
+
+var fileTypeNames = [...]string{
+	FileTypeUnknown:   "unknown",
+	FileTypeFile:      "file",
+	FileTypeSocket:    "socket",
+	FileTypePipe:      "pipe",
+	FileTypeAnonInode: "anon_inode",
+}
+
+// String returns the name of the file type, such as "socket".
+func (t FileType) String() string {
+	if t < 0 || int(t) >= len(fileTypeNames) {
+		return fileTypeNames[FileTypeUnknown]
+	}
+	return fileTypeNames[t]
+}
@@ -0,0 +1,24 @@
+// proci open files unit tests
+package proci
+
+import (
+	"testing"
+)
+
+func TestFileTypeString(t *testing.T) {
+	tests := []struct {
+		fileType FileType
+		expected string
+	}{
+		{FileTypeFile, "file"},
+		{FileTypeSocket, "socket"},
+		{FileTypePipe, "pipe"},
+		{FileTypeAnonInode, "anon_inode"},
+		{FileType(-1), "unknown"},
+	}
+	for _, test := range tests {
+		if test.fileType.String() != test.expected {
+			t.Errorf("Expected %q but it was %q", test.expected, test.fileType.String())
+		}
+	}
+}
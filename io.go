@@ -0,0 +1,58 @@
+package proci
+
+// IORates is the I/O of a process per second between two samples. See
+// IOCounters for the meaning of the fields.
+type IORates struct {
+	ReadCount           float64
+	WriteCount          float64
+	ReadChars           float64
+	WriteChars          float64
+	ReadBytes           float64
+	WriteBytes          float64
+	CancelledWriteBytes float64
+}
+
+// IOSampler calculates the I/O rates of processes, based on how much I/O
+// they have done between two samples.
+//
+// An IOSampler is not safe for concurrent use.
+type IOSampler struct {
+	sampler[IOCounters]
+}
+
+// NewIOSampler creates an IOSampler that reads the I/O counters using the
+// provided Interface implementation.
+func NewIOSampler(p Interface) *IOSampler {
+	return &IOSampler{newSampler(p.GetProcessIOCounters)}
+}
+
+// Sample returns the I/O rates of the process since the previous call to
+// Sample for the same process.
+//
+// The first call for a process returns zero rates since there is nothing to
+// compare with. If the process cannot be read it is forgotten and the error
+// is returned.
+func (c *IOSampler) Sample(pid uint32) (*IORates, error) {
+	previous, current, elapsed, ok, err := c.next(pid)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return &IORates{}, nil
+	}
+	// rate returns 0 if the counter decreased, i.e. the PID has been reused
+	rate := func(previous, current uint64) float64 {
+		if current < previous {
+			return 0
+		}
+		return float64(current-previous) / elapsed.Seconds()
+	}
+	return &IORates{
+		ReadCount:           rate(previous.ReadCount, current.ReadCount),
+		WriteCount:          rate(previous.WriteCount, current.WriteCount),
+		ReadChars:           rate(previous.ReadChars, current.ReadChars),
+		WriteChars:          rate(previous.WriteChars, current.WriteChars),
+		ReadBytes:           rate(previous.ReadBytes, current.ReadBytes),
+		WriteBytes:          rate(previous.WriteBytes, current.WriteBytes),
+		CancelledWriteBytes: rate(previous.CancelledWriteBytes, current.CancelledWriteBytes)}, nil
+}
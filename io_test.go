@@ -0,0 +1,47 @@
+// proci I/O sampler unit tests
+package proci
+
+import (
+	"testing"
+	"time"
+)
+
+func TestIOSampler(t *testing.T) {
+	pm := GenerateMock(2)
+	pm.Processes[1].IOCounters = []IOCounters{
+		{ReadCount: 10, ReadBytes: 1000, WriteBytes: 500, CancelledWriteBytes: 100},
+		{ReadCount: 30, ReadBytes: 11000, WriteBytes: 2500, CancelledWriteBytes: 600},
+		{ReadCount: 5, ReadBytes: 100}} // PID reused
+
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	sampler := NewIOSampler(pm)
+	sampler.now = func() time.Time { return now }
+
+	rates, err := sampler.Sample(1)
+	if err != nil {
+		t.Fatalf("Sample returned error: %s", err)
+	}
+	if *rates != (IORates{}) {
+		t.Errorf("Expected zero rates for first sample but it was %+v", *rates)
+	}
+
+	now = now.Add(10 * time.Second)
+	rates, _ = sampler.Sample(1)
+	expected := IORates{ReadCount: 2, ReadBytes: 1000, WriteBytes: 200, CancelledWriteBytes: 50}
+	if *rates != expected {
+		t.Errorf("Expected %+v but it was %+v", expected, *rates)
+	}
+
+	now = now.Add(10 * time.Second)
+	if rates, _ = sampler.Sample(1); *rates != (IORates{}) {
+		t.Errorf("Expected zero rates when the counters decrease but it was %+v", *rates)
+	}
+
+	pm.Processes[1].DoFailIOCounters = true
+	if _, err = sampler.Sample(1); err == nil {
+		t.Error("Expected error when the counters cannot be read")
+	}
+	if _, found := sampler.previous[1]; found {
+		t.Error("Expected the process to be forgotten after an error")
+	}
+}
@@ -0,0 +1,61 @@
+package proci
+
+import (
+	"sort"
+)
+
+// LimitUsage is how much of a resource limit a process uses.
+type LimitUsage struct {
+	Resource string  // Such as LimitOpenFiles
+	Limit    Limit   // The limit of the resource
+	Used     uint64  // Current usage, in the unit of the limit
+	Percent  float64 // Used in percent of the soft limit, 0 if unlimited
+}
+
+// GetLimitUsage reports how close a process is to its limits on open files
+// (LimitOpenFiles), resident memory (LimitResidentSet) and virtual memory
+// (LimitAddressSpace). The usage is read with GetProcessFdCount and
+// GetProcessMemoryInfo. Resources that are missing in the limits, or whose
+// usage cannot be read, are left out. The result is sorted by Percent,
+// highest first.
+//
+// An error is only returned if the limits cannot be read.
+func GetLimitUsage(p Interface, pid uint32) ([]LimitUsage, error) {
+	limits, err := p.GetProcessLimits(pid)
+	if err != nil {
+		return nil, err
+	}
+	used := make(map[string]uint64)
+	if fdCount, err := p.GetProcessFdCount(pid); err == nil {
+		used[LimitOpenFiles] = uint64(fdCount)
+	}
+	if memoryInfo, err := p.GetProcessMemoryInfo(pid); err == nil {
+		used[LimitResidentSet] = memoryInfo.RSS
+		used[LimitAddressSpace] = memoryInfo.VMS
+	}
+
+	var usages []LimitUsage
+	for resource, value := range used {
+		limit, hasLimit := limits[resource]
+		if !hasLimit {
+			continue
+		}
+		usage := LimitUsage{Resource: resource, Limit: limit, Used: value}
+		switch {
+		case limit.Soft == LimitUnlimited:
+			usage.Percent = 0
+		case limit.Soft == 0:
+			usage.Percent = 100
+		default:
+			usage.Percent = float64(value) / float64(limit.Soft) * 100
+		}
+		usages = append(usages, usage)
+	}
+	sort.Slice(usages, func(i, j int) bool {
+		if usages[i].Percent != usages[j].Percent {
+			return usages[i].Percent > usages[j].Percent
+		}
+		return usages[i].Resource < usages[j].Resource
+	})
+	return usages, nil
+}
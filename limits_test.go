@@ -0,0 +1,42 @@
+// proci limit usage unit tests
+package proci
+
+import (
+	"testing"
+)
+
+func TestGetLimitUsage(t *testing.T) {
+	pm := GenerateMock(2)
+	process := pm.Processes[1]
+	process.OpenFiles = make([]OpenFile, 512)
+	process.MemoryInfo = &MemoryInfo{RSS: 1000, VMS: 4000}
+	process.Limits[LimitResidentSet] = Limit{Soft: 2000, Hard: LimitUnlimited, Unit: "bytes"}
+
+	usages, err := GetLimitUsage(pm, 1)
+	if err != nil {
+		t.Fatalf("GetLimitUsage returned error: %s", err)
+	}
+	expected := []LimitUsage{
+		{Resource: LimitOpenFiles, Limit: process.Limits[LimitOpenFiles], Used: 512, Percent: 50},
+		{Resource: LimitResidentSet, Limit: process.Limits[LimitResidentSet], Used: 1000, Percent: 50},
+		{Resource: LimitAddressSpace, Limit: process.Limits[LimitAddressSpace], Used: 4000, Percent: 0}}
+	if len(usages) != len(expected) {
+		t.Fatalf("Expected %d limit usages but it was %d", len(expected), len(usages))
+	}
+	for i := range expected {
+		if usages[i] != expected[i] {
+			t.Errorf("Expected %+v but it was %+v", expected[i], usages[i])
+		}
+	}
+
+	// Usages that cannot be read are left out
+	process.DoFailOpenFiles = true
+	if usages, _ = GetLimitUsage(pm, 1); len(usages) != 2 {
+		t.Errorf("Expected 2 limit usages but it was %d", len(usages))
+	}
+
+	process.DoFailLimits = true
+	if _, err = GetLimitUsage(pm, 1); err == nil {
+		t.Error("Expected error when the limits cannot be read")
+	}
+}
@@ -0,0 +1,51 @@
+package proci
+
+import (
+	"sort"
+)
+
+// MemoryMapUsage is the memory used by all mapped regions with the same
+// path. The memory values are in bytes.
+type MemoryMapUsage struct {
+	Path      string // Empty for anonymous regions
+	Regions   int    // Number of mapped regions
+	Size      uint64
+	RSS       uint64
+	PSS       uint64
+	Swap      uint64
+	Anonymous uint64
+}
+
+// AggregateMemoryMaps sums the memory maps of a process by path, for
+// example to see which shared libraries use the most memory. The result
+// is sorted by PSS, highest first, and by RSS if the PSS is not available.
+func AggregateMemoryMaps(maps []MemoryMap) []MemoryMapUsage {
+	usages := make(map[string]*MemoryMapUsage)
+	var paths []string
+	for _, memoryMap := range maps {
+		usage := usages[memoryMap.Path]
+		if usage == nil {
+			usage = &MemoryMapUsage{Path: memoryMap.Path}
+			usages[memoryMap.Path] = usage
+			paths = append(paths, memoryMap.Path)
+		}
+		usage.Regions++
+		usage.Size += memoryMap.Size
+		usage.RSS += memoryMap.RSS
+		usage.PSS += memoryMap.PSS
+		usage.Swap += memoryMap.Swap
+		usage.Anonymous += memoryMap.Anonymous
+	}
+
+	result := make([]MemoryMapUsage, 0, len(usages))
+	for _, path := range paths {
+		result = append(result, *usages[path])
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		if result[i].PSS != result[j].PSS {
+			return result[i].PSS > result[j].PSS
+		}
+		return result[i].RSS > result[j].RSS
+	})
+	return result
+}
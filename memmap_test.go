@@ -0,0 +1,32 @@
+// proci memory map aggregation unit tests
+package proci
+
+import (
+	"testing"
+)
+
+func TestAggregateMemoryMaps(t *testing.T) {
+	maps := []MemoryMap{
+		{Path: "/usr/lib/libc.so.6", Size: 100, RSS: 80, PSS: 10},
+		{Path: "[heap]", Size: 400, RSS: 300, PSS: 300, Anonymous: 300, Swap: 50},
+		{Path: "/usr/lib/libc.so.6", Size: 200, RSS: 120, PSS: 15},
+		{Path: "", Size: 100, RSS: 100, PSS: 100, Anonymous: 100},
+		{Path: "[vdso]", Size: 8, RSS: 8}}
+	usages := AggregateMemoryMaps(maps)
+	expected := []MemoryMapUsage{
+		{Path: "[heap]", Regions: 1, Size: 400, RSS: 300, PSS: 300, Swap: 50, Anonymous: 300},
+		{Path: "", Regions: 1, Size: 100, RSS: 100, PSS: 100, Anonymous: 100},
+		{Path: "/usr/lib/libc.so.6", Regions: 2, Size: 300, RSS: 200, PSS: 25},
+		{Path: "[vdso]", Regions: 1, Size: 8, RSS: 8}}
+	if len(usages) != len(expected) {
+		t.Fatalf("Expected %d paths but it was %d", len(expected), len(usages))
+	}
+	for i := range expected {
+		if usages[i] != expected[i] {
+			t.Errorf("Expected %+v but it was %+v", expected[i], usages[i])
+		}
+	}
+	if usages = AggregateMemoryMaps(nil); len(usages) != 0 {
+		t.Errorf("Expected no paths but it was %+v", usages)
+	}
+}
@@ -0,0 +1,84 @@
+package proci
+
+import (
+	"sort"
+)
+
+// Names of the namespace types, as in /proc/<pid>/ns.
+const (
+	NamespaceMnt    = "mnt"
+	NamespacePid    = "pid"
+	NamespaceNet    = "net"
+	NamespaceUTS    = "uts"
+	NamespaceIPC    = "ipc"
+	NamespaceUser   = "user"
+	NamespaceCgroup = "cgroup"
+	NamespaceTime   = "time"
+)
+
+// Inode returns the inode number of the namespace type with the name, such
+// as NamespaceNet. Returns 0 for unknown names.
+func (n Namespaces) Inode(name string) uint64 {
+	switch name {
+	case NamespaceMnt:
+		return n.Mnt
+	case NamespacePid:
+		return n.Pid
+	case NamespaceNet:
+		return n.Net
+	case NamespaceUTS:
+		return n.UTS
+	case NamespaceIPC:
+		return n.IPC
+	case NamespaceUser:
+		return n.User
+	case NamespaceCgroup:
+		return n.Cgroup
+	case NamespaceTime:
+		return n.Time
+	}
+	return 0
+}
+
+// GroupByNamespace groups the processes in a snapshot by their namespace
+// of the type with the name, such as NamespaceNet. The PIDs are keyed by
+// the inode number of the namespace and sorted by PID. Processes whose
+// namespaces could not be read are not included.
+func GroupByNamespace(snapshot *SystemSnapshot, name string) map[uint64][]uint32 {
+	groups := make(map[uint64][]uint32)
+	for _, process := range snapshot.Processes {
+		if process.NamespacesErr != nil || process.Namespaces == nil {
+			continue
+		}
+		inode := process.Namespaces.Inode(name)
+		if inode == 0 {
+			continue
+		}
+		groups[inode] = append(groups[inode], process.Pid)
+	}
+	for _, pids := range groups {
+		sort.Slice(pids, func(i, j int) bool { return pids[i] < pids[j] })
+	}
+	return groups
+}
+
+// SharingNamespace returns the PIDs of the processes in a snapshot that are
+// in the same namespace of the type with the name as the process with the
+// PID, including the process itself. Returns nil if the process is not in
+// the snapshot or its namespaces could not be read.
+func SharingNamespace(snapshot *SystemSnapshot, pid uint32, name string) []uint32 {
+	for _, process := range snapshot.Processes {
+		if process.Pid != pid {
+			continue
+		}
+		if process.NamespacesErr != nil || process.Namespaces == nil {
+			return nil
+		}
+		inode := process.Namespaces.Inode(name)
+		if inode == 0 {
+			return nil
+		}
+		return GroupByNamespace(snapshot, name)[inode]
+	}
+	return nil
+}
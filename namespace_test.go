@@ -0,0 +1,42 @@
+// proci namespace unit tests
+package proci
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGroupByNamespace(t *testing.T) {
+	pm := GenerateMock(5)
+	pm.Processes[3].Namespaces.Net = 100
+	pm.Processes[4].Namespaces.Net = 100
+	pm.Processes[2].DoFailNamespaces = true
+
+	snapshot, err := pm.Snapshot()
+	if err != nil {
+		t.Fatalf("Snapshot returned error: %s", err)
+	}
+	groups := GroupByNamespace(snapshot, NamespaceNet)
+	expected := map[uint64][]uint32{
+		hostNamespaces.Net: {0, 1},
+		100:                {3, 4}}
+	if !reflect.DeepEqual(groups, expected) {
+		t.Errorf("Expected %v but it was %v", expected, groups)
+	}
+	if groups = GroupByNamespace(snapshot, "unknown"); len(groups) != 0 {
+		t.Errorf("Expected no groups for unknown namespace but it was %v", groups)
+	}
+
+	if pids := SharingNamespace(snapshot, 4, NamespaceNet); !reflect.DeepEqual(pids, []uint32{3, 4}) {
+		t.Errorf("Expected [3 4] but it was %v", pids)
+	}
+	if pids := SharingNamespace(snapshot, 4, NamespacePid); !reflect.DeepEqual(pids, []uint32{0, 1, 3, 4}) {
+		t.Errorf("Expected [0 1 3 4] but it was %v", pids)
+	}
+	if pids := SharingNamespace(snapshot, 2, NamespaceNet); pids != nil {
+		t.Errorf("Expected nil for process without namespaces but it was %v", pids)
+	}
+	if pids := SharingNamespace(snapshot, 10, NamespaceNet); pids != nil {
+		t.Errorf("Expected nil for process not in the snapshot but it was %v", pids)
+	}
+}
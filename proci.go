@@ -2,6 +2,30 @@
 // processes.
 package proci
 
+import (
+	"errors"
+	"math"
+	"net/netip"
+	"sort"
+	"time"
+)
+
+// Errors returned by the functions in this package. The errors are usually
+// wrapped with more details, so use errors.Is to check for them.
+var (
+	// ErrProcessNotFound is returned when there is no process with the
+	// requested PID, for example because it has exited.
+	ErrProcessNotFound = errors.New("process not found")
+
+	// ErrAccessDenied is returned when the process exists but the current
+	// user is not allowed to read the requested information.
+	ErrAccessDenied = errors.New("access denied")
+
+	// ErrNotSupported is returned when the requested information is not
+	// available on this platform.
+	ErrNotSupported = errors.New("not supported")
+)
+
 // MemoryStatus reflects the total physical memory utilization.
 type MemoryStatus struct {
 	MemoryLoad uint32 // Current memory load in percent 0-100
@@ -9,70 +33,486 @@ type MemoryStatus struct {
 	AvailPhys  uint64 // Available memory in bytes
 }
 
+// MemoryStatusEx extends MemoryStatus with swap, cache and commit
+// information. All values are in bytes. Values that are not available on
+// the platform are 0.
+type MemoryStatusEx struct {
+	MemoryStatus
+	SwapTotal   uint64 // Total swap (commit limit on Windows)
+	SwapFree    uint64 // Free swap (available commit on Windows)
+	Cached      uint64 // Page cache (Linux only)
+	Buffers     uint64 // Block device buffers (Linux only)
+	Shared      uint64 // Shared memory, including tmpfs (Linux only)
+	Committed   uint64 // Memory committed by all processes
+	CommitLimit uint64 // Memory that can be committed
+}
+
+// MemoryInfo is a breakdown of the memory used by a process. All values are
+// in bytes. Values that are not available on the platform are 0.
+type MemoryInfo struct {
+	RSS     uint64 // Resident set size (working set on Windows)
+	VMS     uint64 // Virtual memory size (page file usage on Windows)
+	Shared  uint64 // Resident memory that can be shared (Linux only)
+	Swap    uint64 // Memory that is swapped out (Linux only)
+	PeakRSS uint64 // Highest resident set size since the process started
+	Private uint64 // Private memory that cannot be shared (Windows only)
+
+	// Proportional set size, i.e. the private memory plus the process share
+	// of the shared memory (Linux only).
+	PSS uint64
+
+	// Unique set size, i.e. resident memory that would be freed if the
+	// process exited (Linux only).
+	USS uint64
+}
+
+// ProcessUser identifies the user and group that a process runs as. The
+// effective IDs are the ones used for access checks, they differ from the
+// real IDs for example when running a setuid program. The names are empty
+// if they are unknown.
+type ProcessUser struct {
+	UID                uint32 // Real user ID
+	GID                uint32 // Real group ID
+	EffectiveUID       uint32
+	EffectiveGID       uint32
+	Username           string // Name of the real user
+	GroupName          string // Name of the real group
+	EffectiveUsername  string
+	EffectiveGroupName string
+}
+
+// CPUTimes is the CPU time a process has used.
+type CPUTimes struct {
+	User   time.Duration // Time executing in user mode
+	System time.Duration // Time executing in kernel mode
+}
+
+// Total returns the sum of the user and system time.
+func (c CPUTimes) Total() time.Duration {
+	return c.User + c.System
+}
+
+// IOCounters holds the I/O a process has done since it was started.
+type IOCounters struct {
+	ReadCount           uint64 // Number of read operations
+	WriteCount          uint64 // Number of write operations
+	ReadChars           uint64 // Bytes read, including from caches and pipes
+	WriteChars          uint64 // Bytes written, including to caches and pipes
+	ReadBytes           uint64 // Bytes read from storage
+	WriteBytes          uint64 // Bytes written to storage
+	CancelledWriteBytes uint64 // Bytes not written to storage since the file was truncated
+}
+
+// Limit is a resource limit of a process.
+type Limit struct {
+	Soft uint64 // The limit that is enforced, LimitUnlimited if no limit
+	Hard uint64 // The ceiling for the soft limit, LimitUnlimited if no limit
+	Unit string // Such as "bytes" or "files", empty if the limit has no unit
+}
+
+// LimitUnlimited is the value of Limit.Soft and Limit.Hard when there is no
+// limit.
+const LimitUnlimited = math.MaxUint64
+
+// Names of some of the resources in the map returned by GetProcessLimits.
+const (
+	LimitOpenFiles    = "Max open files"
+	LimitAddressSpace = "Max address space"
+	LimitResidentSet  = "Max resident set"
+	LimitLockedMemory = "Max locked memory"
+	LimitProcesses    = "Max processes"
+	LimitStackSize    = "Max stack size"
+	LimitCoreFileSize = "Max core file size"
+)
+
+// Cgroup is a control group that a process is a member of.
+type Cgroup struct {
+	HierarchyID int      // 0 for the cgroup v2 unified hierarchy
+	Controllers []string // Such as "cpu" or "memory", empty for cgroup v2
+	Path        string   // Relative to the mount point of the hierarchy
+}
+
+// CgroupResourceStats holds the resource accounting of a cgroup in the
+// cgroup v2 unified hierarchy. Values of controllers that are not enabled
+// for the cgroup are zero, except MemoryMax which is LimitUnlimited.
+type CgroupResourceStats struct {
+	MemoryCurrent uint64                  // Memory charged to the cgroup in bytes
+	MemoryMax     uint64                  // Memory limit in bytes, LimitUnlimited if no limit
+	MemoryStat    map[string]uint64       // Breakdown of the memory, such as "anon" and "file"
+	CPUUsage      time.Duration           // CPU time used by the cgroup
+	CPUUser       time.Duration           // CPU time used in user mode
+	CPUSystem     time.Duration           // CPU time used in kernel mode
+	CPUThrottled  time.Duration           // Time throttled by the CPU limit
+	IO            map[string]CgroupIOStat // Keyed by device number, such as "8:0"
+	PidsCurrent   uint64                  // Number of processes and threads
+}
+
+// CgroupIOStat is the I/O that a cgroup has done on a device.
+type CgroupIOStat struct {
+	ReadBytes    uint64
+	WriteBytes   uint64
+	ReadIOs      uint64
+	WriteIOs     uint64
+	DiscardBytes uint64
+	DiscardIOs   uint64
+}
+
+// Namespaces holds the inode numbers of the Linux namespaces that a process
+// is in. Processes in the same namespace have the same inode number. The
+// inode number is 0 if the kernel does not support the namespace type.
+type Namespaces struct {
+	Mnt    uint64 // Mount namespace
+	Pid    uint64 // PID namespace
+	Net    uint64 // Network namespace
+	UTS    uint64 // Hostname and domain name namespace
+	IPC    uint64 // System V IPC and POSIX message queue namespace
+	User   uint64 // User and group ID namespace
+	Cgroup uint64 // Cgroup root directory namespace
+	Time   uint64 // Boot and monotonic clock namespace
+}
+
+// MemoryMap is a mapped memory region of a process. The memory values are
+// in bytes.
+type MemoryMap struct {
+	StartAddr   uint64 // Start address of the region
+	EndAddr     uint64 // End address of the region, exclusive
+	Permissions string // Such as "r-xp", see proc(5)
+	Offset      uint64 // Offset in the mapped file
+	Device      string // Device of the mapped file as "major:minor"
+	Inode       uint64 // Inode of the mapped file, 0 if no file
+	Path        string // Mapped file or pseudo path such as "[heap]", empty if anonymous
+	Size        uint64 // Size of the region
+	RSS         uint64 // Resident part of the region
+	PSS         uint64 // Proportional share of RSS, see MemoryInfo
+	Swap        uint64 // Swapped out part of the region
+	Anonymous   uint64 // Resident part not backed by a file
+}
+
+// Thread holds information about a single thread of a process.
+type Thread struct {
+	Tid      uint32   // Thread ID
+	Name     string   // Empty if not available
+	State    State    // StateUnknown if not available
+	CPUTimes CPUTimes // CPU time used by the thread
+}
+
+// OpenFile holds information about an open file descriptor of a process.
+type OpenFile struct {
+	Fd       uint32
+	Path     string   // Target, such as "/var/log/app.log" or "socket:[12345]"
+	Type     FileType // Type of the target
+	Position uint64   // Current file offset
+	Flags    uint32   // Flags the file was opened with, such as os.O_RDWR
+}
+
+// Connection holds information about a network connection or a Unix domain
+// socket.
+type Connection struct {
+	Protocol   string         // "tcp", "tcp6", "udp", "udp6" or "unix"
+	LocalAddr  netip.AddrPort // Not set for Unix domain sockets
+	RemoteAddr netip.AddrPort // Not set for Unix domain sockets or if not connected
+	Path       string         // Path of a Unix domain socket, empty if unnamed
+	State      string         // Such as "LISTEN" or "ESTABLISHED"
+	Inode      uint64         // Inode of the socket
+	Pid        uint32         // Owning process, 0 if not known
+}
+
+// Process holds information about a single process. Each piece of information
+// is read separately, so if one of them cannot be read the corresponding
+// error field is set while the other fields are still valid.
+type Process struct {
+	Pid         uint32
+	ParentPid   uint32       // See GetProcessParentPid
+	Path        string       // Path of the process, see GetProcessPath
+	Name        string       // Process name, i.e. the last element of Path
+	CommandLine string       // See GetProcessCommandLine
+	MemoryUsage uint64       // Memory usage in bytes, see GetProcessMemoryUsage
+	User        *ProcessUser // See GetProcessUser
+	StartTime   time.Time    // See GetProcessStartTime
+	State       State        // See GetProcessState
+	ThreadCount int          // Number of threads, see GetProcessThreads
+	Cgroups     []Cgroup     // See GetProcessCgroups
+	Namespaces  *Namespaces  // See GetProcessNamespaces
+
+	// CgroupStats is the accounting of the cgroup v2 cgroup of the process,
+	// see CgroupStats. Only set in snapshots, where processes in the same
+	// cgroup share the same value. nil if the process is not in the cgroup
+	// v2 unified hierarchy.
+	CgroupStats *CgroupResourceStats
+
+	ParentPidErr   error // Set if ParentPid could not be read
+	PathErr        error // Set if Path could not be read
+	CommandLineErr error // Set if CommandLine could not be read
+	MemoryUsageErr error // Set if MemoryUsage could not be read
+	UserErr        error // Set if User could not be read
+	StartTimeErr   error // Set if StartTime could not be read
+	StateErr       error // Set if State could not be read
+	ThreadCountErr error // Set if ThreadCount could not be read
+	CgroupsErr     error // Set if Cgroups could not be read
+	CgroupStatsErr error // Set if CgroupStats could not be read
+	NamespacesErr  error // Set if Namespaces could not be read
+
+	// Exited is set in snapshots if the process exited after it was listed
+	// but before its information could be read. Only Pid is valid then.
+	Exited bool
+
+	// The start time in clock ticks since boot (Linux only). Unlike StartTime
+	// it does not change when the system clock is set, so it is used by Key.
+	startTicks    uint64
+	hasStartTicks bool
+}
+
+// Key returns the identity key of the process.
+func (p Process) Key() ProcessKey {
+	if p.hasStartTicks {
+		return ProcessKey{Pid: p.Pid, StartTime: int64(p.startTicks)}
+	}
+	return newProcessKey(p.Pid, p.StartTime)
+}
+
+// ProcessKey identifies a process across snapshots. Unlike the PID alone it
+// does not match a new process that reuses the PID of an exited process. It
+// can be compared with == and used as map key.
+type ProcessKey struct {
+	Pid uint32
+
+	// Start time in a platform specific unit. On Linux it is clock ticks
+	// since boot, which is not affected by changes of the system clock,
+	// elsewhere nanoseconds since the Unix epoch. Only compare keys returned
+	// by Process.Key and GetProcessKey.
+	StartTime int64
+}
+
+// Returns the identity key of the process with the PID and start time, for
+// platforms where the start time does not depend on the system clock.
+func newProcessKey(pid uint32, startTime time.Time) ProcessKey {
+	key := ProcessKey{Pid: pid}
+	if !startTime.IsZero() {
+		key.StartTime = startTime.UnixNano()
+	}
+	return key
+}
+
+// SystemSnapshot holds the memory status and information about all processes
+// in the system, captured at the same time.
+type SystemSnapshot struct {
+	Time         time.Time // When the snapshot was captured
+	MemoryStatus *MemoryStatus
+	Processes    []Process // Sorted by PID
+}
+
 // Interface is an interface that can be used instead of the separate
 // functions defined in this module. The purpose is to be able to mock the
 // library during testing.
 type Interface interface {
 	GetMemoryStatus() (*MemoryStatus, error)
+	GetMemoryStatusEx() (*MemoryStatusEx, error)
 	GetProcessPids() []uint32
+	ListProcessPids() ([]uint32, error)
 	GetProcessMemoryUsage(pid uint32) (uint64, error)
+	GetProcessMemoryInfo(pid uint32) (*MemoryInfo, error)
 	GetProcessPath(pid uint32) (string, error)
 	GetProcessCommandLine(pid uint32) (string, error)
+	GetProcessArgs(pid uint32) ([]string, error)
+	GetProcessEnviron(pid uint32) (map[string]string, error)
+	GetProcessCwd(pid uint32) (string, error)
+	GetProcessUser(pid uint32) (*ProcessUser, error)
+	GetProcessParentPid(pid uint32) (uint32, error)
+	GetProcessCPUTimes(pid uint32) (*CPUTimes, error)
+	GetProcessIOCounters(pid uint32) (*IOCounters, error)
+	GetProcessStartTime(pid uint32) (time.Time, error)
+	GetProcessKey(pid uint32) (ProcessKey, error)
+	GetProcessState(pid uint32) (State, error)
+	GetProcessThreads(pid uint32) ([]Thread, error)
+	GetProcessOpenFiles(pid uint32) ([]OpenFile, error)
+	GetProcessFdCount(pid uint32) (int, error)
+	GetProcessConnections(pid uint32) ([]Connection, error)
+	GetProcessLimits(pid uint32) (map[string]Limit, error)
+	GetProcessCgroups(pid uint32) ([]Cgroup, error)
+	CgroupStats(path string) (*CgroupResourceStats, error)
+	GetProcessNamespaces(pid uint32) (*Namespaces, error)
+	GetProcessMemoryMaps(pid uint32) ([]MemoryMap, error)
+	Connections() ([]Connection, error)
+	GetProcess(pid uint32) (*Process, error)
+	Snapshot() (*SystemSnapshot, error)
 }
 
-// Proci is this packages implementation of the Interface.
-type Proci struct{}
+// Proci is this packages implementation of the Interface. The zero value
+// uses the default settings, use NewProci to change them.
+type Proci struct {
+	procRoot   string // Where the proc filesystem is mounted (Linux only)
+	etcRoot    string // Where the passwd and group files are (Linux only)
+	cgroupRoot string // Where the cgroup v2 filesystem is mounted (Linux only)
+
+	// Information that is the same for all processes. Only set while taking
+	// a snapshot, so that it is read once instead of once per process.
+	cache *snapshotCache
+}
+
+// Option is a setting that can be passed to NewProci.
+type Option func(*Proci)
+
+// WithProcRoot sets the directory where the proc filesystem is mounted. The
+// default is /proc. This is useful for reading a proc filesystem of another
+// machine or container, or a fixture tree during testing.
+//
+// This option is only used on Linux.
+func WithProcRoot(dir string) Option {
+	return func(s *Proci) {
+		s.procRoot = dir
+	}
+}
+
+// WithEtcRoot sets the directory with the passwd and group files used to
+// look up user and group names. The default is /etc.
+//
+// This option is only used on Linux.
+func WithEtcRoot(dir string) Option {
+	return func(s *Proci) {
+		s.etcRoot = dir
+	}
+}
+
+// WithCgroupRoot sets where the cgroup v2 filesystem is mounted. The
+// default is /sys/fs/cgroup. Use it for testing or when the filesystem is
+// mounted somewhere else, for example in a container.
+//
+// This option is only used on Linux.
+func WithCgroupRoot(dir string) Option {
+	return func(s *Proci) {
+		s.cgroupRoot = dir
+	}
+}
+
+// NewProci creates a Proci with the provided options applied.
+func NewProci(options ...Option) *Proci {
+	s := &Proci{}
+	for _, option := range options {
+		option(s)
+	}
+	return s
+}
 
 // GetMemoryStatus gets the physical memory utilization.
 func (s Proci) GetMemoryStatus() (*MemoryStatus, error) {
-	return getMemoryStatus()
+	return s.getMemoryStatus()
 }
 
 // GetMemoryStatus gets the physical memory utilization.
 func GetMemoryStatus() (*MemoryStatus, error) {
-	return getMemoryStatus()
+	return Proci{}.getMemoryStatus()
+}
+
+// GetMemoryStatusEx gets the physical memory utilization together with
+// swap, cache and commit information.
+func (s Proci) GetMemoryStatusEx() (*MemoryStatusEx, error) {
+	return s.getMemoryStatusEx()
+}
+
+// GetMemoryStatusEx gets the physical memory utilization together with
+// swap, cache and commit information.
+func GetMemoryStatusEx() (*MemoryStatusEx, error) {
+	return Proci{}.getMemoryStatusEx()
 }
 
 // GetProcessPids lists all the process identities (PIDS) running in the system.
 //
 // Returns a slice with PIDS and with the length corresponding to number of PIDS.
+// If the PIDS cannot be listed nil is returned, use ListProcessPids to get
+// the reason.
 //
 // Note that PID 0 is reserved for the idle process in Windows which is special
 // in that you cannot read it with the other functions in this package.
 func (s Proci) GetProcessPids() []uint32 {
-	return getProcessPids()
+	pids, _ := s.listProcessPids()
+	return pids
 }
 
 // GetProcessPids lists all the process identities (PIDS) running in the system.
 //
 // Returns a slice with PIDS and with the length corresponding to number of PIDS.
+// If the PIDS cannot be listed nil is returned, use ListProcessPids to get
+// the reason.
 //
 // Note that PID 0 is reserved for the idle process in Windows which is special
 // in that you cannot read it with the other functions in this package.
 func GetProcessPids() []uint32 {
-	return getProcessPids()
+	pids, _ := Proci{}.listProcessPids()
+	return pids
+}
+
+// ListProcessPids lists all the process identities (PIDS) running in the
+// system. It is the same as GetProcessPids, but it also returns an error if
+// the PIDS cannot be listed.
+func (s Proci) ListProcessPids() ([]uint32, error) {
+	return s.listProcessPids()
+}
+
+// ListProcessPids lists all the process identities (PIDS) running in the
+// system. It is the same as GetProcessPids, but it also returns an error if
+// the PIDS cannot be listed.
+func ListProcessPids() ([]uint32, error) {
+	return Proci{}.listProcessPids()
 }
 
 // GetProcessMemoryUsage gets the number of bytes used by the specific process.
+//
+// This is the Private field of GetProcessMemoryInfo on Windows and the RSS
+// field on Linux, but it is cheaper to get.
 func (s Proci) GetProcessMemoryUsage(pid uint32) (uint64, error) {
-	return getProcessMemoryUsage(pid)
+	return s.getProcessMemoryUsage(pid)
 }
 
 // GetProcessMemoryUsage gets the number of bytes used by the specific process.
+//
+// This is the Private field of GetProcessMemoryInfo on Windows and the RSS
+// field on Linux, but it is cheaper to get.
 func GetProcessMemoryUsage(pid uint32) (uint64, error) {
-	return getProcessMemoryUsage(pid)
+	return Proci{}.getProcessMemoryUsage(pid)
+}
+
+// GetProcessMemoryInfo gets a breakdown of the memory used by the specific
+// process. See MemoryInfo for which values are available on each platform.
+func (s Proci) GetProcessMemoryInfo(pid uint32) (*MemoryInfo, error) {
+	return s.getProcessMemoryInfo(pid)
+}
+
+// GetProcessMemoryInfo gets a breakdown of the memory used by the specific
+// process. See MemoryInfo for which values are available on each platform.
+func GetProcessMemoryInfo(pid uint32) (*MemoryInfo, error) {
+	return Proci{}.getProcessMemoryInfo(pid)
+}
+
+// GetProcessMemoryMaps gets the mapped memory regions of the process,
+// sorted by address. Use AggregateMemoryMaps to see which files use the
+// most memory.
+//
+// RSS, PSS, Swap and Anonymous are 0 if the kernel does not provide them.
+// Not supported on Windows.
+func (s Proci) GetProcessMemoryMaps(pid uint32) ([]MemoryMap, error) {
+	return s.getProcessMemoryMaps(pid)
+}
+
+// GetProcessMemoryMaps gets the mapped memory regions of the process,
+// sorted by address. Use AggregateMemoryMaps to see which files use the
+// most memory.
+//
+// RSS, PSS, Swap and Anonymous are 0 if the kernel does not provide them.
+// Not supported on Windows.
+func GetProcessMemoryMaps(pid uint32) ([]MemoryMap, error) {
+	return Proci{}.getProcessMemoryMaps(pid)
 }
 
 // GetProcessPath gets the path of the process (which also includes the
 // process name).
 func (s Proci) GetProcessPath(pid uint32) (string, error) {
-	return getProcessPath(pid)
+	return s.getProcessPath(pid)
 }
 
 // GetProcessPath gets the path of the process (which also includes the
 // process name).
 func GetProcessPath(pid uint32) (string, error) {
-	return getProcessPath(pid)
+	return Proci{}.getProcessPath(pid)
 }
 
 // GetProcessCommandLine reads the process command line. This function
@@ -82,7 +522,7 @@ func GetProcessPath(pid uint32) (string, error) {
 // Also note that some system processes (usually the ones with lowest PIDs)
 // will give "Access Denied" even if you are running as administrator.
 func (s Proci) GetProcessCommandLine(pid uint32) (string, error) {
-	return getProcessCommandLine(pid)
+	return s.getProcessCommandLine(pid)
 }
 
 // GetProcessCommandLine reads the process command line. This function
@@ -92,5 +532,437 @@ func (s Proci) GetProcessCommandLine(pid uint32) (string, error) {
 // Also note that some system processes (usually the ones with lowest PIDs)
 // will give "Access Denied" even if you are running as administrator.
 func GetProcessCommandLine(pid uint32) (string, error) {
-	return getProcessCommandLine(pid)
+	return Proci{}.getProcessCommandLine(pid)
+}
+
+// GetProcessArgs reads the process command line as separate arguments, where
+// the first argument is the program. See GetProcessCommandLine for required
+// access rights.
+//
+// On Linux the arguments are exactly the ones the process was started with.
+// On Windows the command line is a single string that is split using the
+// same rules as CommandLineToArgvW.
+func (s Proci) GetProcessArgs(pid uint32) ([]string, error) {
+	return s.getProcessArgs(pid)
+}
+
+// GetProcessArgs reads the process command line as separate arguments, where
+// the first argument is the program. See GetProcessCommandLine for required
+// access rights.
+//
+// On Linux the arguments are exactly the ones the process was started with.
+// On Windows the command line is a single string that is split using the
+// same rules as CommandLineToArgvW.
+func GetProcessArgs(pid uint32) ([]string, error) {
+	return Proci{}.getProcessArgs(pid)
+}
+
+// GetProcessEnviron reads the environment variables of the process. On Linux
+// these are the variables the process was started with. On Windows these
+// are the current variables, including changes made by the process itself.
+//
+// Reading the environment of processes owned by other users requires
+// administrator (root) rights, otherwise an error wrapping ErrAccessDenied
+// is returned.
+func (s Proci) GetProcessEnviron(pid uint32) (map[string]string, error) {
+	return s.getProcessEnviron(pid)
+}
+
+// GetProcessEnviron reads the environment variables of the process. On Linux
+// these are the variables the process was started with. On Windows these
+// are the current variables, including changes made by the process itself.
+//
+// Reading the environment of processes owned by other users requires
+// administrator (root) rights, otherwise an error wrapping ErrAccessDenied
+// is returned.
+func GetProcessEnviron(pid uint32) (map[string]string, error) {
+	return Proci{}.getProcessEnviron(pid)
+}
+
+// GetProcessCwd gets the current working directory of the process. See
+// GetProcessEnviron for required access rights.
+func (s Proci) GetProcessCwd(pid uint32) (string, error) {
+	return s.getProcessCwd(pid)
+}
+
+// GetProcessCwd gets the current working directory of the process. See
+// GetProcessEnviron for required access rights.
+func GetProcessCwd(pid uint32) (string, error) {
+	return Proci{}.getProcessCwd(pid)
+}
+
+// GetProcessUser gets the user and group that the process runs as.
+//
+// This is only supported on Linux, where the names are read from the passwd
+// and group files in the directory set with WithEtcRoot.
+func (s Proci) GetProcessUser(pid uint32) (*ProcessUser, error) {
+	return s.getProcessUser(pid)
+}
+
+// GetProcessUser gets the user and group that the process runs as.
+//
+// This is only supported on Linux, where the names are read from the passwd
+// and group files in the directory set with WithEtcRoot.
+func GetProcessUser(pid uint32) (*ProcessUser, error) {
+	return Proci{}.getProcessUser(pid)
+}
+
+// GetProcessParentPid gets the PID of the process that started the process.
+//
+// Note that the parent process might have exited, and on Windows its PID
+// might even have been reused by another process.
+func (s Proci) GetProcessParentPid(pid uint32) (uint32, error) {
+	return s.getProcessParentPid(pid)
+}
+
+// GetProcessParentPid gets the PID of the process that started the process.
+//
+// Note that the parent process might have exited, and on Windows its PID
+// might even have been reused by another process.
+func GetProcessParentPid(pid uint32) (uint32, error) {
+	return Proci{}.getProcessParentPid(pid)
+}
+
+// GetProcessCPUTimes gets the CPU time the process has used since it was
+// started. Use CPUSampler to calculate the CPU usage in percent.
+func (s Proci) GetProcessCPUTimes(pid uint32) (*CPUTimes, error) {
+	return s.getProcessCPUTimes(pid)
+}
+
+// GetProcessCPUTimes gets the CPU time the process has used since it was
+// started. Use CPUSampler to calculate the CPU usage in percent.
+func GetProcessCPUTimes(pid uint32) (*CPUTimes, error) {
+	return Proci{}.getProcessCPUTimes(pid)
+}
+
+// GetProcessIOCounters gets the I/O the process has done since it was
+// started. Use IOSampler to calculate the I/O rates.
+//
+// On Windows the storage I/O cannot be separated from other I/O, so
+// ReadBytes and WriteBytes are the same as ReadChars and WriteChars, and
+// CancelledWriteBytes is 0.
+func (s Proci) GetProcessIOCounters(pid uint32) (*IOCounters, error) {
+	return s.getProcessIOCounters(pid)
+}
+
+// GetProcessIOCounters gets the I/O the process has done since it was
+// started. Use IOSampler to calculate the I/O rates.
+//
+// On Windows the storage I/O cannot be separated from other I/O, so
+// ReadBytes and WriteBytes are the same as ReadChars and WriteChars, and
+// CancelledWriteBytes is 0.
+func GetProcessIOCounters(pid uint32) (*IOCounters, error) {
+	return Proci{}.getProcessIOCounters(pid)
+}
+
+// GetProcessStartTime gets the time when the process was started. Together
+// with the PID it identifies the process, see GetProcessKey. Use
+// time.Since to get how long the process has been running.
+//
+// On Linux the start time has a resolution of 10 ms.
+func (s Proci) GetProcessStartTime(pid uint32) (time.Time, error) {
+	return s.getProcessStartTime(pid)
+}
+
+// GetProcessStartTime gets the time when the process was started. Together
+// with the PID it identifies the process, see GetProcessKey. Use
+// time.Since to get how long the process has been running.
+//
+// On Linux the start time has a resolution of 10 ms.
+func GetProcessStartTime(pid uint32) (time.Time, error) {
+	return Proci{}.getProcessStartTime(pid)
+}
+
+// GetProcessKey gets the identity key of the process, the same as Key of
+// the Process returned by GetProcess. Use it to recognize a process across
+// snapshots, even if its PID is reused.
+func (s Proci) GetProcessKey(pid uint32) (ProcessKey, error) {
+	return s.getProcessKey(pid)
+}
+
+// GetProcessKey gets the identity key of the process, the same as Key of
+// the Process returned by GetProcess. Use it to recognize a process across
+// snapshots, even if its PID is reused.
+func GetProcessKey(pid uint32) (ProcessKey, error) {
+	return Proci{}.getProcessKey(pid)
+}
+
+// GetProcessState gets the run state of the process, such as StateRunning or
+// StateZombie. Use FindZombies to find the zombies in a snapshot.
+//
+// Not supported on Windows.
+func (s Proci) GetProcessState(pid uint32) (State, error) {
+	return s.getProcessState(pid)
+}
+
+// GetProcessState gets the run state of the process, such as StateRunning or
+// StateZombie. Use FindZombies to find the zombies in a snapshot.
+//
+// Not supported on Windows.
+func GetProcessState(pid uint32) (State, error) {
+	return Proci{}.getProcessState(pid)
+}
+
+// GetProcessThreads gets the threads of the process, sorted by thread ID.
+// Threads that exit while they are read are left out.
+//
+// On Windows the thread names and states are not available, and the CPU
+// times are zero for threads that cannot be opened.
+func (s Proci) GetProcessThreads(pid uint32) ([]Thread, error) {
+	return s.getProcessThreads(pid)
+}
+
+// GetProcessThreads gets the threads of the process, sorted by thread ID.
+// Threads that exit while they are read are left out.
+//
+// On Windows the thread names and states are not available, and the CPU
+// times are zero for threads that cannot be opened.
+func GetProcessThreads(pid uint32) ([]Thread, error) {
+	return Proci{}.getProcessThreads(pid)
+}
+
+// GetProcessOpenFiles gets the open file descriptors of the process, sorted
+// by file descriptor number. File descriptors that are closed while they are
+// read are left out. Use GetProcessFdCount if only the number is needed.
+//
+// Not supported on Windows.
+func (s Proci) GetProcessOpenFiles(pid uint32) ([]OpenFile, error) {
+	return s.getProcessOpenFiles(pid)
+}
+
+// GetProcessOpenFiles gets the open file descriptors of the process, sorted
+// by file descriptor number. File descriptors that are closed while they are
+// read are left out. Use GetProcessFdCount if only the number is needed.
+//
+// Not supported on Windows.
+func GetProcessOpenFiles(pid uint32) ([]OpenFile, error) {
+	return Proci{}.getProcessOpenFiles(pid)
+}
+
+// GetProcessFdCount gets the number of open file descriptors of the process.
+// It is cheaper than GetProcessOpenFiles since the file descriptors are only
+// counted.
+//
+// On Windows the number of open handles is returned.
+func (s Proci) GetProcessFdCount(pid uint32) (int, error) {
+	return s.getProcessFdCount(pid)
+}
+
+// GetProcessFdCount gets the number of open file descriptors of the process.
+// It is cheaper than GetProcessOpenFiles since the file descriptors are only
+// counted.
+//
+// On Windows the number of open handles is returned.
+func GetProcessFdCount(pid uint32) (int, error) {
+	return Proci{}.getProcessFdCount(pid)
+}
+
+// GetProcessConnections gets the network connections and Unix domain sockets
+// that the process has open, in the order tcp, tcp6, udp, udp6 and unix.
+//
+// Not supported on Windows.
+func (s Proci) GetProcessConnections(pid uint32) ([]Connection, error) {
+	return s.getProcessConnections(pid)
+}
+
+// GetProcessConnections gets the network connections and Unix domain sockets
+// that the process has open, in the order tcp, tcp6, udp, udp6 and unix.
+//
+// Not supported on Windows.
+func GetProcessConnections(pid uint32) ([]Connection, error) {
+	return Proci{}.getProcessConnections(pid)
+}
+
+// Connections gets all network connections and Unix domain sockets in the
+// system, in the order tcp, tcp6, udp, udp6 and unix. The owning process is
+// found by searching the open files of all processes, so it is only known
+// for processes that can be accessed.
+//
+// Not supported on Windows.
+func (s Proci) Connections() ([]Connection, error) {
+	return s.connections()
+}
+
+// Connections gets all network connections and Unix domain sockets in the
+// system, in the order tcp, tcp6, udp, udp6 and unix. The owning process is
+// found by searching the open files of all processes, so it is only known
+// for processes that can be accessed.
+//
+// Not supported on Windows.
+func Connections() ([]Connection, error) {
+	return Proci{}.connections()
+}
+
+// GetProcessLimits gets the resource limits of the process, keyed by the
+// resource name such as LimitOpenFiles. Use GetLimitUsage to see how close
+// the process is to its limits.
+//
+// Not supported on Windows.
+func (s Proci) GetProcessLimits(pid uint32) (map[string]Limit, error) {
+	return s.getProcessLimits(pid)
+}
+
+// GetProcessLimits gets the resource limits of the process, keyed by the
+// resource name such as LimitOpenFiles. Use GetLimitUsage to see how close
+// the process is to its limits.
+//
+// Not supported on Windows.
+func GetProcessLimits(pid uint32) (map[string]Limit, error) {
+	return Proci{}.getProcessLimits(pid)
+}
+
+// GetProcessCgroups gets the control groups the process is a member of, one
+// per hierarchy. Use DetectContainer to find out if the process runs in a
+// container.
+//
+// Not supported on Windows.
+func (s Proci) GetProcessCgroups(pid uint32) ([]Cgroup, error) {
+	return s.getProcessCgroups(pid)
+}
+
+// GetProcessCgroups gets the control groups the process is a member of, one
+// per hierarchy. Use DetectContainer to find out if the process runs in a
+// container.
+//
+// Not supported on Windows.
+func GetProcessCgroups(pid uint32) ([]Cgroup, error) {
+	return Proci{}.getProcessCgroups(pid)
+}
+
+// CgroupStats gets the resource accounting of a cgroup in the cgroup v2
+// unified hierarchy. The path is relative to the mount point, as in the
+// Path of the Cgroup with HierarchyID 0 returned by GetProcessCgroups.
+//
+// Not supported on Windows.
+func (s Proci) CgroupStats(path string) (*CgroupResourceStats, error) {
+	return s.cgroupStats(path)
+}
+
+// CgroupStats gets the resource accounting of a cgroup in the cgroup v2
+// unified hierarchy. The path is relative to the mount point, as in the
+// Path of the Cgroup with HierarchyID 0 returned by GetProcessCgroups.
+//
+// Not supported on Windows.
+func CgroupStats(path string) (*CgroupResourceStats, error) {
+	return Proci{}.cgroupStats(path)
+}
+
+// GetProcessNamespaces gets the inode numbers of the namespaces that the
+// process is in. Use GroupByNamespace to find the processes that share a
+// namespace.
+//
+// Not supported on Windows.
+func (s Proci) GetProcessNamespaces(pid uint32) (*Namespaces, error) {
+	return s.getProcessNamespaces(pid)
+}
+
+// GetProcessNamespaces gets the inode numbers of the namespaces that the
+// process is in. Use GroupByNamespace to find the processes that share a
+// namespace.
+//
+// Not supported on Windows.
+func GetProcessNamespaces(pid uint32) (*Namespaces, error) {
+	return Proci{}.getProcessNamespaces(pid)
+}
+
+// GetProcess gets all the information in Process for a process in one call.
+// This is more efficient than calling the separate functions since the
+// process is only opened once.
+//
+// An error is only returned if the process cannot be opened at all, for
+// example if it does not exist. Errors reading the separate fields are set
+// in the returned Process.
+func (s Proci) GetProcess(pid uint32) (*Process, error) {
+	return s.getProcess(pid)
+}
+
+// GetProcess gets all the information in Process for a process in one call.
+// This is more efficient than calling the separate functions since the
+// process is only opened once.
+//
+// An error is only returned if the process cannot be opened at all, for
+// example if it does not exist. Errors reading the separate fields are set
+// in the returned Process.
+func GetProcess(pid uint32) (*Process, error) {
+	return Proci{}.getProcess(pid)
+}
+
+// Snapshot captures the memory status and information about all processes
+// in the system. Processes that exit during the capture are included but
+// marked as Exited. Processes that can be listed but not opened have the
+// error set in all error fields.
+//
+// An error is only returned if the memory status or the processes cannot be
+// read at all.
+func (s Proci) Snapshot() (*SystemSnapshot, error) {
+	return s.snapshot()
+}
+
+// Snapshot captures the memory status and information about all processes
+// in the system. Processes that exit during the capture are included but
+// marked as Exited. Processes that can be listed but not opened have the
+// error set in all error fields.
+//
+// An error is only returned if the memory status or the processes cannot be
+// read at all.
+func Snapshot() (*SystemSnapshot, error) {
+	return Proci{}.snapshot()
+}
+
+// snapshot implements Snapshot.
+func (s Proci) snapshot() (*SystemSnapshot, error) {
+	s.cache = s.newSnapshotCache()
+	return takeSnapshot(s)
+}
+
+// takeSnapshot implements Snapshot for any Interface implementation.
+func takeSnapshot(p Interface) (*SystemSnapshot, error) {
+	snapshot := &SystemSnapshot{Time: time.Now()}
+	memoryStatus, err := p.GetMemoryStatus()
+	if err != nil {
+		return nil, err
+	}
+	snapshot.MemoryStatus = memoryStatus
+	pids, err := p.ListProcessPids()
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(pids, func(i, j int) bool { return pids[i] < pids[j] })
+	snapshot.Processes = make([]Process, 0, len(pids))
+	// Processes in the same cgroup share the cgroup statistics
+	type cgroupStatsResult struct {
+		stats *CgroupResourceStats
+		err   error
+	}
+	cgroupStats := make(map[string]cgroupStatsResult)
+	for _, pid := range pids {
+		process, err := p.GetProcess(pid)
+		if err != nil {
+			process = &Process{
+				Pid:            pid,
+				ParentPidErr:   err,
+				PathErr:        err,
+				CommandLineErr: err,
+				MemoryUsageErr: err,
+				UserErr:        err,
+				StartTimeErr:   err,
+				StateErr:       err,
+				ThreadCountErr: err,
+				CgroupsErr:     err,
+				CgroupStatsErr: err,
+				NamespacesErr:  err,
+				Exited:         errors.Is(err, ErrProcessNotFound)}
+		} else if process.CgroupsErr != nil {
+			process.CgroupStatsErr = process.CgroupsErr
+		} else if path, found := unifiedCgroupPath(process.Cgroups); found {
+			result, cached := cgroupStats[path]
+			if !cached {
+				result.stats, result.err = p.CgroupStats(path)
+				cgroupStats[path] = result
+			}
+			process.CgroupStats, process.CgroupStatsErr = result.stats, result.err
+		}
+		snapshot.Processes = append(snapshot.Processes, *process)
+	}
+	return snapshot, nil
 }
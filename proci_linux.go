@@ -0,0 +1,1457 @@
+// Package proci implements the proci interface for Linux
+package proci
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+//////////////////////////////////////////////////////////////////////////////
+// Internal variables
+
+// Default mount point of the proc filesystem
+const defaultProcRoot = "/proc"
+
+// Default directory of the passwd and group files
+const defaultEtcRoot = "/etc"
+
+// Default mount point of the cgroup v2 filesystem
+const defaultCgroupRoot = "/sys/fs/cgroup"
+
+// Number of clock ticks per second used for times in the proc filesystem
+// (USER_HZ). It is 100 on all common architectures.
+const clockTicks = 100
+
+//////////////////////////////////////////////////////////////////////////////
+// Get physical memory status
+
+// getMemoryStatus implements GetMemoryStatus.
+func (s Proci) getMemoryStatus() (*MemoryStatus, error) {
+	memoryStatusEx, err := s.getMemoryStatusEx()
+	if err != nil {
+		return &MemoryStatus{}, err
+	}
+	return &memoryStatusEx.MemoryStatus, nil
+}
+
+// getMemoryStatusEx implements GetMemoryStatusEx.
+func (s Proci) getMemoryStatusEx() (*MemoryStatusEx, error) {
+	meminfo, err := readKeyValueFile(s.procPath("meminfo"))
+	if err != nil {
+		return nil, fmt.Errorf("unable to get physical memory info. Reason: %s", err)
+	}
+
+	totalPhys := meminfo.uint("MemTotal")
+	availPhys, hasAvail := meminfo.lookupUint("MemAvailable")
+	if !hasAvail {
+		// Kernels older than 3.14 don't provide MemAvailable
+		availPhys = meminfo.uint("MemFree") + meminfo.uint("Buffers") + meminfo.uint("Cached")
+	}
+
+	var memoryLoad uint32
+	if totalPhys > 0 && availPhys <= totalPhys {
+		memoryLoad = uint32((totalPhys - availPhys) * 100 / totalPhys)
+	}
+
+	return &MemoryStatusEx{
+		MemoryStatus: MemoryStatus{
+			MemoryLoad: memoryLoad,
+			TotalPhys:  totalPhys,
+			AvailPhys:  availPhys},
+		SwapTotal:   meminfo.uint("SwapTotal"),
+		SwapFree:    meminfo.uint("SwapFree"),
+		Cached:      meminfo.uint("Cached"),
+		Buffers:     meminfo.uint("Buffers"),
+		Shared:      meminfo.uint("Shmem"),
+		Committed:   meminfo.uint("Committed_AS"),
+		CommitLimit: meminfo.uint("CommitLimit")}, nil
+}
+
+//////////////////////////////////////////////////////////////////////////////
+// List processes
+
+// listProcessPids implements ListProcessPids
+func (s Proci) listProcessPids() ([]uint32, error) {
+	entries, err := os.ReadDir(s.procPath(""))
+	if err != nil {
+		return nil, fmt.Errorf("unable to list processes. Reason: %s", err)
+	}
+	pids := make([]uint32, 0, len(entries))
+	for _, entry := range entries {
+		pid, err := strconv.ParseUint(entry.Name(), 10, 32)
+		if err != nil || !entry.IsDir() {
+			continue // Not a process directory
+		}
+		pids = append(pids, uint32(pid))
+	}
+	sort.Slice(pids, func(i, j int) bool { return pids[i] < pids[j] })
+	return pids, nil
+}
+
+//////////////////////////////////////////////////////////////////////////////
+// Get process memory utilization
+
+// getProcessMemoryUsage implements GetProcessMemoryUsage. On Linux the
+// resident set size (VmRSS) is used.
+func (s Proci) getProcessMemoryUsage(pid uint32) (uint64, error) {
+	status, err := readKeyValueFile(s.pidPath(pid, "status"))
+	if err != nil {
+		return 0, s.procError(pid, "memory usage", err)
+	}
+	return memoryInfoFromStatus(status).RSS, nil
+}
+
+// getProcessMemoryInfo implements GetProcessMemoryInfo. PSS and USS are
+// only set if /proc/<pid>/smaps_rollup is readable, which requires Linux 4.14
+// and the same access rights as reading the memory of the process.
+func (s Proci) getProcessMemoryInfo(pid uint32) (*MemoryInfo, error) {
+	status, err := readKeyValueFile(s.pidPath(pid, "status"))
+	if err != nil {
+		return nil, s.procError(pid, "memory info", err)
+	}
+	memoryInfo := memoryInfoFromStatus(status)
+
+	if rollup, err := readKeyValueFile(s.pidPath(pid, "smaps_rollup")); err == nil {
+		memoryInfo.PSS = rollup.uint("Pss")
+		memoryInfo.USS = rollup.uint("Private_Clean") + rollup.uint("Private_Dirty")
+	}
+	return memoryInfo, nil
+}
+
+// Returns the memory information available in /proc/<pid>/status. Kernel
+// threads don't have any memory entries, i.e. they use 0 bytes.
+func memoryInfoFromStatus(status keyValues) *MemoryInfo {
+	return &MemoryInfo{
+		RSS:     status.uint("VmRSS"),
+		VMS:     status.uint("VmSize"),
+		Shared:  status.uint("RssFile") + status.uint("RssShmem"),
+		Swap:    status.uint("VmSwap"),
+		PeakRSS: status.uint("VmHWM")}
+}
+
+//////////////////////////////////////////////////////////////////////////////
+// Get process memory maps
+
+// getProcessMemoryMaps implements GetProcessMemoryMaps.
+func (s Proci) getProcessMemoryMaps(pid uint32) ([]MemoryMap, error) {
+	file, err := os.Open(s.pidPath(pid, "smaps"))
+	if errors.Is(err, fs.ErrNotExist) {
+		// Kernels without CONFIG_PROC_PAGE_MONITOR only have maps
+		file, err = os.Open(s.pidPath(pid, "maps"))
+	}
+	if err != nil {
+		return nil, s.procError(pid, "memory maps", err)
+	}
+	defer file.Close()
+
+	// smaps has the same lines as maps, each followed by "Key: Value"
+	// lines with the values of the mapping
+	var maps []MemoryMap
+	var values []keyValues
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		if key, value, found := strings.Cut(line, ":"); found && !strings.Contains(key, " ") {
+			if len(values) > 0 {
+				values[len(values)-1][key] = strings.TrimSpace(value)
+			}
+			continue
+		}
+		memoryMap, err := parseMapsLine(line)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read memory maps of process %d. Reason: %s", pid, err)
+		}
+		maps = append(maps, *memoryMap)
+		values = append(values, make(keyValues))
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, s.procError(pid, "memory maps", err)
+	}
+	for i := range maps {
+		maps[i].RSS = values[i].uint("Rss")
+		maps[i].PSS = values[i].uint("Pss")
+		maps[i].Swap = values[i].uint("Swap")
+		maps[i].Anonymous = values[i].uint("Anonymous")
+	}
+	return maps, nil
+}
+
+// Parses a line in /proc/<pid>/maps, such as
+// "55d0c8a00000-55d0c8a21000 r--p 00000000 08:01 1312345   /usr/bin/python3".
+func parseMapsLine(line string) (*MemoryMap, error) {
+	fields := strings.Fields(line)
+	if len(fields) < 5 {
+		return nil, fmt.Errorf("invalid maps line %q", line)
+	}
+	start, end, found := strings.Cut(fields[0], "-")
+	startAddr, startErr := strconv.ParseUint(start, 16, 64)
+	endAddr, endErr := strconv.ParseUint(end, 16, 64)
+	offset, offsetErr := strconv.ParseUint(fields[2], 16, 64)
+	inode, inodeErr := strconv.ParseUint(fields[4], 10, 64)
+	if !found || startErr != nil || endErr != nil || offsetErr != nil || inodeErr != nil || endAddr < startAddr {
+		return nil, fmt.Errorf("invalid maps line %q", line)
+	}
+	// The path may contain spaces, so use the rest of the line after the
+	// first five fields
+	return &MemoryMap{
+		StartAddr:   startAddr,
+		EndAddr:     endAddr,
+		Permissions: fields[1],
+		Offset:      offset,
+		Device:      fields[3],
+		Inode:       inode,
+		Path:        lineAfterFields(line, fields[:5]),
+		Size:        endAddr - startAddr}, nil
+}
+
+//////////////////////////////////////////////////////////////////////////////
+// Get process path (which also includes the process name).
+
+// getProcessPath implements GetProcessPath.
+func (s Proci) getProcessPath(pid uint32) (string, error) {
+	path, err := os.Readlink(s.pidPath(pid, "exe"))
+	if errors.Is(err, fs.ErrNotExist) {
+		if _, staterr := os.Stat(s.pidPath(pid, "")); staterr == nil {
+			// The process exists but has no executable, for example kernel
+			// threads. The path is empty.
+			return "", nil
+		}
+	}
+	if err != nil {
+		return "", s.procError(pid, "path", err)
+	}
+	return path, nil
+}
+
+//////////////////////////////////////////////////////////////////////////////
+// Get process command line arguments
+
+// getProcessCommandLine implements GetProcessCommandLine. The arguments are
+// joined with spaces.
+func (s Proci) getProcessCommandLine(pid uint32) (string, error) {
+	cmdline, err := os.ReadFile(s.pidPath(pid, "cmdline"))
+	if err != nil {
+		return "", s.procError(pid, "command line", err)
+	}
+	return joinCommandLine(cmdline), nil
+}
+
+// getProcessArgs implements GetProcessArgs. Note that a process can modify
+// its own arguments, some processes for example join them with spaces.
+func (s Proci) getProcessArgs(pid uint32) ([]string, error) {
+	cmdline, err := os.ReadFile(s.pidPath(pid, "cmdline"))
+	if err != nil {
+		return nil, s.procError(pid, "arguments", err)
+	}
+	return splitNulSeparated(cmdline), nil
+}
+
+// Converts the NUL separated arguments in /proc/<pid>/cmdline to a string
+// where the arguments are separated with spaces.
+func joinCommandLine(cmdline []byte) string {
+	cmdline = bytes.TrimRight(cmdline, "\x00")
+	return string(bytes.ReplaceAll(cmdline, []byte{0}, []byte{' '}))
+}
+
+// Splits NUL separated strings, such as the content of /proc/<pid>/cmdline.
+func splitNulSeparated(data []byte) []string {
+	data = bytes.TrimRight(data, "\x00")
+	if len(data) == 0 {
+		return []string{}
+	}
+	return strings.Split(string(data), "\x00")
+}
+
+//////////////////////////////////////////////////////////////////////////////
+// Get process environment variables
+
+// getProcessEnviron implements GetProcessEnviron.
+func (s Proci) getProcessEnviron(pid uint32) (map[string]string, error) {
+	environ, err := os.ReadFile(s.pidPath(pid, "environ"))
+	if err != nil {
+		return nil, s.procError(pid, "environment", err)
+	}
+	return parseEnviron(splitNulSeparated(environ)), nil
+}
+
+//////////////////////////////////////////////////////////////////////////////
+// Get process current working directory
+
+// getProcessCwd implements GetProcessCwd.
+func (s Proci) getProcessCwd(pid uint32) (string, error) {
+	cwd, err := os.Readlink(s.pidPath(pid, "cwd"))
+	if err != nil {
+		return "", s.procError(pid, "working directory", err)
+	}
+	return cwd, nil
+}
+
+//////////////////////////////////////////////////////////////////////////////
+// Get process user
+
+// getProcessUser implements GetProcessUser.
+func (s Proci) getProcessUser(pid uint32) (*ProcessUser, error) {
+	status, err := readKeyValueFile(s.pidPath(pid, "status"))
+	if err != nil {
+		return nil, s.procError(pid, "user", err)
+	}
+	return s.userFromStatus(status)
+}
+
+// Returns the user of the Uid and Gid lines in /proc/<pid>/status. Each line
+// has the real, effective, saved set and filesystem ID.
+func (s Proci) userFromStatus(status keyValues) (*ProcessUser, error) {
+	uids := strings.Fields(status["Uid"])
+	gids := strings.Fields(status["Gid"])
+	if len(uids) < 2 || len(gids) < 2 {
+		return nil, fmt.Errorf("unable to read user. Reason: invalid Uid or Gid in status")
+	}
+	var ids [4]uint32
+	for i, id := range []string{uids[0], uids[1], gids[0], gids[1]} {
+		value, err := strconv.ParseUint(id, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read user. Reason: %s", err)
+		}
+		ids[i] = uint32(value)
+	}
+	passwd := s.etcPath("passwd")
+	group := s.etcPath("group")
+	return &ProcessUser{
+		UID:                ids[0],
+		EffectiveUID:       ids[1],
+		GID:                ids[2],
+		EffectiveGID:       ids[3],
+		Username:           lookupIDName(passwd, ids[0]),
+		EffectiveUsername:  lookupIDName(passwd, ids[1]),
+		GroupName:          lookupIDName(group, ids[2]),
+		EffectiveGroupName: lookupIDName(group, ids[3])}, nil
+}
+
+// Cache of the names in passwd and group files. A file is read again if it
+// has been modified.
+var idNamesCache = struct {
+	sync.Mutex
+	files map[string]*idNamesFile
+}{files: make(map[string]*idNamesFile)}
+
+type idNamesFile struct {
+	modTime time.Time
+	size    int64
+	names   map[uint32]string
+}
+
+// Looks up the name of a user or group ID in a file with the passwd or group
+// format, i.e. "name:password:ID:..." lines. Returns an empty string if the
+// ID or the file does not exist.
+func lookupIDName(path string, id uint32) string {
+	info, err := os.Stat(path)
+	if err != nil {
+		return ""
+	}
+	idNamesCache.Lock()
+	defer idNamesCache.Unlock()
+	file := idNamesCache.files[path]
+	if file == nil || !file.modTime.Equal(info.ModTime()) || file.size != info.Size() {
+		file = &idNamesFile{
+			modTime: info.ModTime(),
+			size:    info.Size(),
+			names:   readIDNames(path)}
+		idNamesCache.files[path] = file
+	}
+	return file.names[id]
+}
+
+// Reads the names of a file with the passwd or group format. If an ID
+// exists several times the first name is used, like getpwuid does.
+func readIDNames(path string) map[uint32]string {
+	names := make(map[uint32]string)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return names
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		fields := strings.Split(line, ":")
+		if len(fields) < 3 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		id, err := strconv.ParseUint(fields[2], 10, 32)
+		if err != nil {
+			continue
+		}
+		if _, exists := names[uint32(id)]; !exists {
+			names[uint32(id)] = fields[0]
+		}
+	}
+	return names
+}
+
+//////////////////////////////////////////////////////////////////////////////
+// Get parent process
+
+// getProcessParentPid implements GetProcessParentPid.
+func (s Proci) getProcessParentPid(pid uint32) (uint32, error) {
+	stat, err := readStat(s.pidPath(pid, "stat"))
+	if err != nil {
+		return 0, s.procError(pid, "parent PID", err)
+	}
+	return stat.ParentPid, nil
+}
+
+//////////////////////////////////////////////////////////////////////////////
+// Get process CPU times
+
+// getProcessCPUTimes implements GetProcessCPUTimes.
+func (s Proci) getProcessCPUTimes(pid uint32) (*CPUTimes, error) {
+	stat, err := readStat(s.pidPath(pid, "stat"))
+	if err != nil {
+		return nil, s.procError(pid, "CPU times", err)
+	}
+	return stat.cpuTimes(), nil
+}
+
+//////////////////////////////////////////////////////////////////////////////
+// Get process I/O counters
+
+// getProcessIOCounters implements GetProcessIOCounters.
+func (s Proci) getProcessIOCounters(pid uint32) (*IOCounters, error) {
+	io, err := readKeyValueFile(s.pidPath(pid, "io"))
+	if err != nil {
+		return nil, s.procError(pid, "I/O counters", err)
+	}
+	return &IOCounters{
+		ReadCount:           io.uint("syscr"),
+		WriteCount:          io.uint("syscw"),
+		ReadChars:           io.uint("rchar"),
+		WriteChars:          io.uint("wchar"),
+		ReadBytes:           io.uint("read_bytes"),
+		WriteBytes:          io.uint("write_bytes"),
+		CancelledWriteBytes: io.uint("cancelled_write_bytes")}, nil
+}
+
+//////////////////////////////////////////////////////////////////////////////
+// Get process start time
+
+// getProcessStartTime implements GetProcessStartTime.
+func (s Proci) getProcessStartTime(pid uint32) (time.Time, error) {
+	stat, err := readStat(s.pidPath(pid, "stat"))
+	if err != nil {
+		return time.Time{}, s.procError(pid, "start time", err)
+	}
+	return s.startTime(pid, stat)
+}
+
+// getProcessKey implements GetProcessKey.
+func (s Proci) getProcessKey(pid uint32) (ProcessKey, error) {
+	stat, err := readStat(s.pidPath(pid, "stat"))
+	if err != nil {
+		return ProcessKey{}, s.procError(pid, "start time", err)
+	}
+	return ProcessKey{Pid: pid, StartTime: int64(stat.StartTime)}, nil
+}
+
+// Returns the start time in the stat of the process. The boot time is taken
+// from the snapshot cache if set, otherwise it is read.
+func (s Proci) startTime(pid uint32, stat *procStat) (time.Time, error) {
+	cache := s.cache
+	if cache == nil {
+		cache = s.newSnapshotCache()
+	}
+	if cache.bootTimeErr != nil {
+		return time.Time{}, fmt.Errorf("unable to get start time of process %d. Reason: %s", pid, cache.bootTimeErr)
+	}
+	// The start time is in clock ticks since boot
+	return cache.bootTime.Add(ticksToDuration(stat.StartTime)), nil
+}
+
+// Returns when the system was booted, read from the btime line in
+// /proc/stat.
+func (s Proci) bootTime() (time.Time, error) {
+	data, err := os.ReadFile(s.procPath("stat"))
+	if err != nil {
+		return time.Time{}, err
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 || fields[0] != "btime" {
+			continue
+		}
+		seconds, err := strconv.ParseInt(fields[1], 10, 64)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid boot time. Reason: %s", err)
+		}
+		return time.Unix(seconds, 0), nil
+	}
+	return time.Time{}, fmt.Errorf("boot time not found")
+}
+
+//////////////////////////////////////////////////////////////////////////////
+// Get process state
+
+// getProcessState implements GetProcessState.
+func (s Proci) getProcessState(pid uint32) (State, error) {
+	stat, err := readStat(s.pidPath(pid, "stat"))
+	if err != nil {
+		return StateUnknown, s.procError(pid, "state", err)
+	}
+	return parseState(stat.State), nil
+}
+
+// Converts the state letter in /proc/<pid>/stat to a State. See proc(5).
+func parseState(letter string) State {
+	switch letter {
+	case "R":
+		return StateRunning
+	case "S", "W", "P":
+		// W (paging) and P (parked) are only used by old kernels
+		return StateSleeping
+	case "D":
+		return StateDiskSleep
+	case "T", "t":
+		return StateStopped
+	case "Z":
+		return StateZombie
+	case "X", "x":
+		return StateDead
+	case "I":
+		return StateIdle
+	}
+	return StateUnknown
+}
+
+//////////////////////////////////////////////////////////////////////////////
+// Get process threads
+
+// getProcessThreads implements GetProcessThreads.
+func (s Proci) getProcessThreads(pid uint32) ([]Thread, error) {
+	entries, err := os.ReadDir(s.pidPath(pid, "task"))
+	if err != nil {
+		return nil, s.procError(pid, "threads", err)
+	}
+	threads := make([]Thread, 0, len(entries))
+	for _, entry := range entries {
+		tid, err := strconv.ParseUint(entry.Name(), 10, 32)
+		if err != nil {
+			continue
+		}
+		stat, err := readStat(s.pidPath(pid, filepath.Join("task", entry.Name(), "stat")))
+		if errors.Is(err, fs.ErrNotExist) || errors.Is(err, syscall.ESRCH) {
+			// The thread exited after the directory was read
+			continue
+		}
+		if err != nil {
+			return nil, s.procError(pid, "threads", err)
+		}
+		threads = append(threads, Thread{
+			Tid:      uint32(tid),
+			Name:     stat.Name,
+			State:    parseState(stat.State),
+			CPUTimes: *stat.cpuTimes()})
+	}
+	sort.Slice(threads, func(i, j int) bool { return threads[i].Tid < threads[j].Tid })
+	return threads, nil
+}
+
+//////////////////////////////////////////////////////////////////////////////
+// Get process open files
+
+// getProcessOpenFiles implements GetProcessOpenFiles.
+func (s Proci) getProcessOpenFiles(pid uint32) ([]OpenFile, error) {
+	names, err := readDirNames(s.pidPath(pid, "fd"))
+	if err != nil {
+		return nil, s.procError(pid, "open files", err)
+	}
+	files := make([]OpenFile, 0, len(names))
+	for _, name := range names {
+		fd, err := strconv.ParseUint(name, 10, 32)
+		if err != nil {
+			continue
+		}
+		target, err := os.Readlink(s.pidPath(pid, filepath.Join("fd", name)))
+		if err == nil {
+			var fdinfo keyValues
+			fdinfo, err = readKeyValueFile(s.pidPath(pid, filepath.Join("fdinfo", name)))
+			if err == nil {
+				flags, _ := strconv.ParseUint(fdinfo["flags"], 8, 32)
+				files = append(files, OpenFile{
+					Fd:       uint32(fd),
+					Path:     target,
+					Type:     fileType(target),
+					Position: fdinfo.uint("pos"),
+					Flags:    uint32(flags)})
+				continue
+			}
+		}
+		if errors.Is(err, fs.ErrNotExist) {
+			// The file descriptor was closed after the directory was read
+			continue
+		}
+		return nil, s.procError(pid, "open files", err)
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].Fd < files[j].Fd })
+	return files, nil
+}
+
+// Returns the type of the target of a file descriptor link in
+// /proc/<pid>/fd.
+func fileType(target string) FileType {
+	switch {
+	case strings.HasPrefix(target, "/"):
+		return FileTypeFile
+	case strings.HasPrefix(target, "socket:"):
+		return FileTypeSocket
+	case strings.HasPrefix(target, "pipe:"):
+		return FileTypePipe
+	case strings.HasPrefix(target, "anon_inode:"):
+		return FileTypeAnonInode
+	}
+	return FileTypeUnknown
+}
+
+// getProcessFdCount implements GetProcessFdCount.
+func (s Proci) getProcessFdCount(pid uint32) (int, error) {
+	names, err := readDirNames(s.pidPath(pid, "fd"))
+	if err != nil {
+		return 0, s.procError(pid, "file descriptor count", err)
+	}
+	return len(names), nil
+}
+
+//////////////////////////////////////////////////////////////////////////////
+// Get process limits
+
+// getProcessLimits implements GetProcessLimits.
+func (s Proci) getProcessLimits(pid uint32) (map[string]Limit, error) {
+	data, err := os.ReadFile(s.pidPath(pid, "limits"))
+	if err != nil {
+		return nil, s.procError(pid, "limits", err)
+	}
+	limits, err := parseLimits(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("unable to read limits of process %d. Reason: %s", pid, err)
+	}
+	return limits, nil
+}
+
+// Parses the content of a /proc/<pid>/limits file. The resource names
+// contain spaces, so the columns are located using the header.
+func parseLimits(data string) (map[string]Limit, error) {
+	lines := strings.Split(data, "\n")
+	softStart := strings.Index(lines[0], "Soft Limit")
+	hardStart := strings.Index(lines[0], "Hard Limit")
+	unitStart := strings.Index(lines[0], "Units")
+	if softStart < 0 || hardStart < softStart || unitStart < hardStart {
+		return nil, fmt.Errorf("invalid limits header %q", lines[0])
+	}
+	limits := make(map[string]Limit)
+	for _, line := range lines[1:] {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		// column returns the trimmed text from start up to end, or up to
+		// the end of the line if end is -1
+		column := func(start, end int) string {
+			if start >= len(line) {
+				return ""
+			}
+			if end < 0 || end > len(line) {
+				end = len(line)
+			}
+			return strings.TrimSpace(line[start:end])
+		}
+		soft, err := parseLimitValue(column(softStart, hardStart))
+		if err != nil {
+			return nil, err
+		}
+		hard, err := parseLimitValue(column(hardStart, unitStart))
+		if err != nil {
+			return nil, err
+		}
+		limits[column(0, softStart)] = Limit{Soft: soft, Hard: hard, Unit: column(unitStart, -1)}
+	}
+	return limits, nil
+}
+
+// Parses a soft or hard limit value in a /proc/<pid>/limits file.
+func parseLimitValue(value string) (uint64, error) {
+	if value == "unlimited" {
+		return LimitUnlimited, nil
+	}
+	limit, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid limit %q", value)
+	}
+	return limit, nil
+}
+
+//////////////////////////////////////////////////////////////////////////////
+// Get process cgroups
+
+// getProcessCgroups implements GetProcessCgroups.
+func (s Proci) getProcessCgroups(pid uint32) ([]Cgroup, error) {
+	data, err := os.ReadFile(s.pidPath(pid, "cgroup"))
+	if err != nil {
+		return nil, s.procError(pid, "cgroups", err)
+	}
+	cgroups, err := parseCgroups(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("unable to read cgroups of process %d. Reason: %s", pid, err)
+	}
+	return cgroups, nil
+}
+
+// Parses the content of a /proc/<pid>/cgroup file. Each line is
+// "hierarchy-ID:controller-list:cgroup-path", see cgroups(7).
+func parseCgroups(data string) ([]Cgroup, error) {
+	var cgroups []Cgroup
+	for _, line := range strings.Split(data, "\n") {
+		if line == "" {
+			continue
+		}
+		// The path may contain colons
+		parts := strings.SplitN(line, ":", 3)
+		if len(parts) != 3 {
+			return nil, fmt.Errorf("invalid cgroup line %q", line)
+		}
+		id, err := strconv.Atoi(parts[0])
+		if err != nil {
+			return nil, fmt.Errorf("invalid cgroup hierarchy ID %q", parts[0])
+		}
+		cgroup := Cgroup{HierarchyID: id, Path: parts[2]}
+		if parts[1] != "" {
+			cgroup.Controllers = strings.Split(parts[1], ",")
+		}
+		cgroups = append(cgroups, cgroup)
+	}
+	return cgroups, nil
+}
+
+//////////////////////////////////////////////////////////////////////////////
+// Get cgroup statistics
+
+// cgroupStats implements CgroupStats.
+func (s Proci) cgroupStats(path string) (*CgroupResourceStats, error) {
+	dir, err := s.cgroupPath(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read cgroup %s. Reason: %s", path, err)
+	}
+	// Every cgroup v2 directory has a cgroup.controllers file. On hybrid
+	// hosts the default root is a tmpfs with the v1 hierarchies instead.
+	root, _ := s.cgroupPath("/")
+	if _, err = os.Stat(filepath.Join(root, "cgroup.controllers")); errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("%w: no cgroup v2 filesystem mounted at %s", ErrNotSupported, root)
+	}
+	if _, err = os.Stat(dir); err != nil {
+		return nil, fmt.Errorf("unable to read cgroup %s. Reason: %s", path, err)
+	}
+
+	stats := &CgroupResourceStats{MemoryMax: LimitUnlimited}
+	// read parses a file of the cgroup, unless an earlier file failed.
+	// Missing files are skipped, since they only exist if the controller is
+	// enabled for the cgroup.
+	read := func(name string, parse func(data string) error) {
+		if err != nil {
+			return
+		}
+		data, readErr := os.ReadFile(filepath.Join(dir, name))
+		if errors.Is(readErr, fs.ErrNotExist) {
+			return
+		}
+		if readErr == nil {
+			readErr = parse(strings.TrimSpace(string(data)))
+		}
+		if readErr != nil {
+			err = fmt.Errorf("unable to read %s of cgroup %s. Reason: %s", name, path, readErr)
+		}
+	}
+	read("memory.current", func(data string) (err error) {
+		stats.MemoryCurrent, err = parseCgroupValue(data)
+		return err
+	})
+	read("memory.max", func(data string) (err error) {
+		stats.MemoryMax, err = parseCgroupValue(data)
+		return err
+	})
+	read("memory.stat", func(data string) (err error) {
+		stats.MemoryStat, err = parseFlatKeyed(data)
+		return err
+	})
+	read("cpu.stat", func(data string) error {
+		cpuStat, err := parseFlatKeyed(data)
+		stats.CPUUsage = time.Duration(cpuStat["usage_usec"]) * time.Microsecond
+		stats.CPUUser = time.Duration(cpuStat["user_usec"]) * time.Microsecond
+		stats.CPUSystem = time.Duration(cpuStat["system_usec"]) * time.Microsecond
+		stats.CPUThrottled = time.Duration(cpuStat["throttled_usec"]) * time.Microsecond
+		return err
+	})
+	read("io.stat", func(data string) (err error) {
+		stats.IO, err = parseIOStat(data)
+		return err
+	})
+	read("pids.current", func(data string) (err error) {
+		stats.PidsCurrent, err = parseCgroupValue(data)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
+
+// Parses a single value cgroup file, such as memory.max. The value "max"
+// is returned as LimitUnlimited.
+func parseCgroupValue(data string) (uint64, error) {
+	if data == "max" {
+		return LimitUnlimited, nil
+	}
+	return strconv.ParseUint(data, 10, 64)
+}
+
+// Parses a cgroup file with "key value" lines, such as memory.stat.
+func parseFlatKeyed(data string) (map[string]uint64, error) {
+	values := make(map[string]uint64)
+	for _, line := range strings.Split(data, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			continue
+		}
+		value, err := strconv.ParseUint(fields[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value for %s. Reason: %s", fields[0], err)
+		}
+		values[fields[0]] = value
+	}
+	return values, nil
+}
+
+// Parses the content of an io.stat file. Each line is a device number
+// followed by "key=value" fields.
+func parseIOStat(data string) (map[string]CgroupIOStat, error) {
+	devices := make(map[string]CgroupIOStat)
+	for _, line := range strings.Split(data, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		var stat CgroupIOStat
+		for _, field := range fields[1:] {
+			key, text, found := strings.Cut(field, "=")
+			if !found {
+				continue
+			}
+			value, err := strconv.ParseUint(text, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid value for %s. Reason: %s", key, err)
+			}
+			switch key {
+			case "rbytes":
+				stat.ReadBytes = value
+			case "wbytes":
+				stat.WriteBytes = value
+			case "rios":
+				stat.ReadIOs = value
+			case "wios":
+				stat.WriteIOs = value
+			case "dbytes":
+				stat.DiscardBytes = value
+			case "dios":
+				stat.DiscardIOs = value
+			}
+		}
+		devices[fields[0]] = stat
+	}
+	return devices, nil
+}
+
+//////////////////////////////////////////////////////////////////////////////
+// Get process namespaces
+
+// getProcessNamespaces implements GetProcessNamespaces.
+func (s Proci) getProcessNamespaces(pid uint32) (*Namespaces, error) {
+	namespaces := &Namespaces{}
+	links := []struct {
+		name  string
+		inode *uint64
+	}{
+		{NamespaceMnt, &namespaces.Mnt},
+		{NamespacePid, &namespaces.Pid},
+		{NamespaceNet, &namespaces.Net},
+		{NamespaceUTS, &namespaces.UTS},
+		{NamespaceIPC, &namespaces.IPC},
+		{NamespaceUser, &namespaces.User},
+		{NamespaceCgroup, &namespaces.Cgroup},
+		{NamespaceTime, &namespaces.Time},
+	}
+	found := false
+	for _, link := range links {
+		target, err := os.Readlink(s.pidPath(pid, filepath.Join("ns", link.name)))
+		if errors.Is(err, fs.ErrNotExist) {
+			continue // Not supported by the kernel
+		}
+		if err != nil {
+			return nil, s.procError(pid, "namespaces", err)
+		}
+		inode, err := namespaceInode(link.name, target)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read namespaces of process %d. Reason: %s", pid, err)
+		}
+		*link.inode = inode
+		found = true
+	}
+	if !found {
+		return nil, s.procError(pid, "namespaces", fs.ErrNotExist)
+	}
+	return namespaces, nil
+}
+
+// Returns the inode number of a namespace link target such as
+// "net:[4026531840]".
+func namespaceInode(name string, target string) (uint64, error) {
+	value, found := strings.CutPrefix(target, name+":[")
+	if !found || !strings.HasSuffix(value, "]") {
+		return 0, fmt.Errorf("invalid %s namespace %q", name, target)
+	}
+	inode, err := strconv.ParseUint(strings.TrimSuffix(value, "]"), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s namespace %q", name, target)
+	}
+	return inode, nil
+}
+
+//////////////////////////////////////////////////////////////////////////////
+// Get network connections
+
+// The files in the net directory of the proc filesystem that are read, in
+// the order they are read
+var connectionProtocols = []string{"tcp", "tcp6", "udp", "udp6", "unix"}
+
+// Names of the states in the tcp and udp files, see include/net/tcp_states.h
+var inetStates = map[uint64]string{
+	0x01: "ESTABLISHED",
+	0x02: "SYN_SENT",
+	0x03: "SYN_RECV",
+	0x04: "FIN_WAIT1",
+	0x05: "FIN_WAIT2",
+	0x06: "TIME_WAIT",
+	0x07: "CLOSE",
+	0x08: "CLOSE_WAIT",
+	0x09: "LAST_ACK",
+	0x0A: "LISTEN",
+	0x0B: "CLOSING",
+	0x0C: "NEW_SYN_RECV",
+}
+
+// Names of the states in the unix file, see include/uapi/linux/net.h
+var unixStates = map[uint64]string{
+	0x01: "UNCONNECTED",
+	0x02: "CONNECTING",
+	0x03: "CONNECTED",
+	0x04: "DISCONNECTING",
+}
+
+// Flag in the unix file for listening sockets (__SO_ACCEPTCON)
+const unixAcceptCon = 0x00010000
+
+// getProcessConnections implements GetProcessConnections.
+func (s Proci) getProcessConnections(pid uint32) ([]Connection, error) {
+	inodes, err := s.socketInodes(pid)
+	if err != nil {
+		return nil, s.procError(pid, "connections", err)
+	}
+	// Read the net directory of the process, since the process might be
+	// in another network namespace than this process
+	connections, err := readConnections(s.pidPath(pid, "net"))
+	if err != nil {
+		return nil, s.procError(pid, "connections", err)
+	}
+	owned := make([]Connection, 0, len(inodes))
+	for _, connection := range connections {
+		if inodes[connection.Inode] {
+			connection.Pid = pid
+			owned = append(owned, connection)
+		}
+	}
+	return owned, nil
+}
+
+// connections implements Connections.
+func (s Proci) connections() ([]Connection, error) {
+	connections, err := readConnections(s.procPath("net"))
+	if err != nil {
+		return nil, fmt.Errorf("unable to get connections. Reason: %s", err)
+	}
+	pids, err := s.listProcessPids()
+	if err != nil {
+		return nil, err
+	}
+	// A socket may be shared by several processes, use the one with the
+	// lowest PID
+	owners := make(map[uint64]uint32)
+	for _, pid := range pids {
+		inodes, err := s.socketInodes(pid)
+		if err != nil {
+			continue // Exited or not accessible
+		}
+		for inode := range inodes {
+			if _, found := owners[inode]; !found {
+				owners[inode] = pid
+			}
+		}
+	}
+	for i := range connections {
+		connections[i].Pid = owners[connections[i].Inode]
+	}
+	return connections, nil
+}
+
+// Returns the inodes of the sockets that a process has open.
+func (s Proci) socketInodes(pid uint32) (map[uint64]bool, error) {
+	names, err := readDirNames(s.pidPath(pid, "fd"))
+	if err != nil {
+		return nil, err
+	}
+	inodes := make(map[uint64]bool)
+	for _, name := range names {
+		target, err := os.Readlink(s.pidPath(pid, filepath.Join("fd", name)))
+		if err != nil {
+			continue // Closed after the directory was read
+		}
+		if inode, found := socketInode(target); found {
+			inodes[inode] = true
+		}
+	}
+	return inodes, nil
+}
+
+// Returns the inode of a "socket:[<inode>]" file descriptor target.
+func socketInode(target string) (uint64, bool) {
+	value, found := strings.CutPrefix(target, "socket:[")
+	if !found || !strings.HasSuffix(value, "]") {
+		return 0, false
+	}
+	inode, err := strconv.ParseUint(strings.TrimSuffix(value, "]"), 10, 64)
+	return inode, err == nil
+}
+
+// Reads the connections in the files of a net directory in the proc
+// filesystem. Missing files are skipped, for example if IPv6 is disabled.
+func readConnections(netDir string) ([]Connection, error) {
+	var connections []Connection
+	for _, protocol := range connectionProtocols {
+		data, err := os.ReadFile(filepath.Join(netDir, protocol))
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		var parsed []Connection
+		if protocol == "unix" {
+			parsed, err = parseUnixConnections(string(data))
+		} else {
+			parsed, err = parseInetConnections(protocol, string(data))
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s file. Reason: %s", protocol, err)
+		}
+		connections = append(connections, parsed...)
+	}
+	return connections, nil
+}
+
+// Parses the content of a tcp, tcp6, udp or udp6 file.
+func parseInetConnections(protocol string, data string) ([]Connection, error) {
+	var connections []Connection
+	lines := strings.Split(data, "\n")
+	for _, line := range lines[1:] { // Skip the header
+		fields := strings.Fields(line)
+		if len(fields) < 10 {
+			continue
+		}
+		localAddr, err := parseInetAddress(fields[1])
+		if err != nil {
+			return nil, err
+		}
+		remoteAddr, err := parseInetAddress(fields[2])
+		if err != nil {
+			return nil, err
+		}
+		state, err := strconv.ParseUint(fields[3], 16, 8)
+		if err != nil {
+			return nil, fmt.Errorf("invalid state. Reason: %s", err)
+		}
+		inode, err := strconv.ParseUint(fields[9], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid inode. Reason: %s", err)
+		}
+		connection := Connection{
+			Protocol:   protocol,
+			LocalAddr:  localAddr,
+			RemoteAddr: remoteAddr,
+			State:      inetStates[state],
+			Inode:      inode}
+		if strings.HasPrefix(protocol, "udp") && connection.State == "CLOSE" {
+			// UDP sockets use the CLOSE state when they are not connected
+			connection.State = "UNCONNECTED"
+		}
+		connections = append(connections, connection)
+	}
+	return connections, nil
+}
+
+// Parses an address such as "0100007F:1F90" in a tcp, tcp6, udp or udp6
+// file. The zero value is returned for the unspecified address with port 0.
+func parseInetAddress(address string) (netip.AddrPort, error) {
+	hexIP, hexPort, found := strings.Cut(address, ":")
+	if !found {
+		return netip.AddrPort{}, fmt.Errorf("invalid address %q", address)
+	}
+	ip, err := hex.DecodeString(hexIP)
+	if err != nil || (len(ip) != 4 && len(ip) != 16) {
+		return netip.AddrPort{}, fmt.Errorf("invalid address %q", address)
+	}
+	port, err := strconv.ParseUint(hexPort, 16, 16)
+	if err != nil {
+		return netip.AddrPort{}, fmt.Errorf("invalid port in address %q", address)
+	}
+	// The address is written as 32 bit words in host byte order
+	for i := 0; i < len(ip); i += 4 {
+		binary.BigEndian.PutUint32(ip[i:], binary.NativeEndian.Uint32(ip[i:]))
+	}
+	addr, _ := netip.AddrFromSlice(ip)
+	if addr.IsUnspecified() && port == 0 {
+		return netip.AddrPort{}, nil
+	}
+	return netip.AddrPortFrom(addr, uint16(port)), nil
+}
+
+// Parses the content of a unix file.
+func parseUnixConnections(data string) ([]Connection, error) {
+	var connections []Connection
+	lines := strings.Split(data, "\n")
+	for _, line := range lines[1:] { // Skip the header
+		fields := strings.Fields(line)
+		if len(fields) < 7 {
+			continue
+		}
+		flags, err := strconv.ParseUint(fields[3], 16, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid flags. Reason: %s", err)
+		}
+		state, err := strconv.ParseUint(fields[5], 16, 8)
+		if err != nil {
+			return nil, fmt.Errorf("invalid state. Reason: %s", err)
+		}
+		inode, err := strconv.ParseUint(fields[6], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid inode. Reason: %s", err)
+		}
+		// The path may contain spaces and tabs, so use the rest of the line
+		// after the first seven fields
+		connection := Connection{
+			Protocol: "unix",
+			Path:     lineAfterFields(line, fields[:7]),
+			State:    unixStates[state],
+			Inode:    inode}
+		if flags&unixAcceptCon != 0 {
+			connection.State = "LISTEN"
+		}
+		connections = append(connections, connection)
+	}
+	return connections, nil
+}
+
+//////////////////////////////////////////////////////////////////////////////
+// Get process information in one call
+
+// getProcess implements GetProcess.
+func (s Proci) getProcess(pid uint32) (*Process, error) {
+	status, err := readKeyValueFile(s.pidPath(pid, "status"))
+	if err != nil {
+		err = s.procError(pid, "status", err)
+		if errors.Is(err, ErrProcessNotFound) {
+			return nil, err
+		}
+	}
+
+	process := &Process{Pid: pid}
+	// The parent PID, start time and state are all read from the same stat
+	// file, so that they belong to the same process even if the PID is reused
+	if stat, err := readStat(s.pidPath(pid, "stat")); err == nil {
+		process.ParentPid = stat.ParentPid
+		process.startTicks, process.hasStartTicks = stat.StartTime, true
+		process.StartTime, process.StartTimeErr = s.startTime(pid, stat)
+		process.State = parseState(stat.State)
+	} else {
+		process.ParentPidErr = s.procError(pid, "parent PID", err)
+		process.StartTimeErr = s.procError(pid, "start time", err)
+		process.StateErr = s.procError(pid, "state", err)
+	}
+	process.Path, process.PathErr = s.getProcessPath(pid)
+	if process.Path != "" {
+		process.Name = filepath.Base(process.Path)
+	} else if status != nil {
+		// Kernel threads have no path, use the name from the status file
+		process.Name = status["Name"]
+	}
+	process.CommandLine, process.CommandLineErr = s.getProcessCommandLine(pid)
+	process.Cgroups, process.CgroupsErr = s.getProcessCgroups(pid)
+	process.Namespaces, process.NamespacesErr = s.getProcessNamespaces(pid)
+	if err != nil {
+		process.MemoryUsageErr = err
+		process.UserErr = err
+		process.ThreadCountErr = err
+	} else {
+		process.MemoryUsage = memoryInfoFromStatus(status).RSS
+		process.User, process.UserErr = s.userFromStatus(status)
+		if threadCount, found := status.lookupUint("Threads"); found {
+			process.ThreadCount = int(threadCount)
+		} else {
+			process.ThreadCountErr = fmt.Errorf("%w: thread count of process %d not found", ErrNotSupported, pid)
+		}
+	}
+	return process, nil
+}
+
+//////////////////////////////////////////////////////////////////////////////
+// Internal functions
+
+// Information that is read once per snapshot, see Proci.
+type snapshotCache struct {
+	bootTime    time.Time
+	bootTimeErr error
+}
+
+// Reads the information that is the same for all processes.
+func (s Proci) newSnapshotCache() *snapshotCache {
+	cache := &snapshotCache{}
+	cache.bootTime, cache.bootTimeErr = s.bootTime()
+	return cache
+}
+
+// Returns the path to a file in the proc filesystem. If name is empty the
+// path of the proc filesystem root is returned.
+func (s Proci) procPath(name string) string {
+	root := s.procRoot
+	if root == "" {
+		root = defaultProcRoot
+	}
+	return filepath.Join(root, name)
+}
+
+// Returns the path to a file in the directory with the passwd and group
+// files.
+func (s Proci) etcPath(name string) string {
+	root := s.etcRoot
+	if root == "" {
+		root = defaultEtcRoot
+	}
+	return filepath.Join(root, name)
+}
+
+// Returns the path to a cgroup directory in the cgroup v2 filesystem.
+// Paths outside of the cgroup filesystem, such as those of processes in
+// another cgroup namespace, are rejected.
+func (s Proci) cgroupPath(path string) (string, error) {
+	root := s.cgroupRoot
+	if root == "" {
+		root = defaultCgroupRoot
+	}
+	dir := filepath.Join(root, path)
+	if rel, err := filepath.Rel(root, dir); err != nil || rel == ".." || strings.HasPrefix(rel, "../") {
+		return "", fmt.Errorf("cgroup path %s is outside of %s", path, root)
+	}
+	return dir, nil
+}
+
+// Returns the path to a file in the proc directory of a process. If name
+// is empty the path of the process directory itself is returned.
+func (s Proci) pidPath(pid uint32, name string) string {
+	return s.procPath(filepath.Join(strconv.FormatUint(uint64(pid), 10), name))
+}
+
+// Converts an error from reading the proc directory of a process into an
+// error that wraps ErrProcessNotFound, ErrAccessDenied or ErrNotSupported
+// when applicable. what describes the information that was read.
+func (s Proci) procError(pid uint32, what string, err error) error {
+	var sentinel error
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		if _, staterr := os.Stat(s.pidPath(pid, "")); staterr == nil {
+			// The process exists, but not the file. Old kernel?
+			sentinel = ErrNotSupported
+		} else {
+			sentinel = ErrProcessNotFound
+		}
+	case errors.Is(err, syscall.ESRCH):
+		// The process exited while reading
+		sentinel = ErrProcessNotFound
+	case errors.Is(err, fs.ErrPermission):
+		sentinel = ErrAccessDenied
+	default:
+		return fmt.Errorf("unable to read %s of process %d. Reason: %s", what, pid, err)
+	}
+	return fmt.Errorf("%w: unable to read %s of process %d. Reason: %s", sentinel, what, pid, err)
+}
+
+// The fields used by this package from /proc/<pid>/stat. See proc(5) for
+// details.
+type procStat struct {
+	Name       string // Field 2, comm
+	State      string // Field 3
+	ParentPid  uint32 // Field 4
+	UserTime   uint64 // Field 14, utime in clock ticks
+	SystemTime uint64 // Field 15, stime in clock ticks
+	StartTime  uint64 // Field 22, starttime in clock ticks since boot
+}
+
+// Reads a /proc/<pid>/stat (or /proc/<pid>/task/<tid>/stat) file.
+func readStat(path string) (*procStat, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return parseStat(string(data))
+}
+
+// Parses the content of a /proc/<pid>/stat file.
+func parseStat(data string) (*procStat, error) {
+	// The name may contain spaces and parentheses, so search for the last
+	// parenthesis to find where the remaining fields start.
+	nameStart := strings.IndexByte(data, '(')
+	nameEnd := strings.LastIndexByte(data, ')')
+	if nameStart < 0 || nameEnd < nameStart {
+		return nil, fmt.Errorf("invalid stat format")
+	}
+	fields := strings.Fields(data[nameEnd+1:])
+	// field returns the field with number n (as numbered in proc(5))
+	field := func(n int) string {
+		if n-3 >= len(fields) {
+			return ""
+		}
+		return fields[n-3]
+	}
+	if len(fields) < 20 {
+		return nil, fmt.Errorf("invalid stat format. Too few fields: %d", len(fields)+2)
+	}
+	parentPid, err := strconv.ParseUint(field(4), 10, 32)
+	if err != nil {
+		return nil, fmt.Errorf("invalid stat parent PID. Reason: %s", err)
+	}
+	userTime, err := strconv.ParseUint(field(14), 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid stat user time. Reason: %s", err)
+	}
+	systemTime, err := strconv.ParseUint(field(15), 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid stat system time. Reason: %s", err)
+	}
+	startTime, err := strconv.ParseUint(field(22), 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid stat start time. Reason: %s", err)
+	}
+	return &procStat{
+		Name:       data[nameStart+1 : nameEnd],
+		State:      field(3),
+		ParentPid:  uint32(parentPid),
+		UserTime:   userTime,
+		SystemTime: systemTime,
+		StartTime:  startTime}, nil
+}
+
+// Returns the CPU times of the stat
+func (stat *procStat) cpuTimes() *CPUTimes {
+	return &CPUTimes{
+		User:   ticksToDuration(stat.UserTime),
+		System: ticksToDuration(stat.SystemTime)}
+}
+
+// Converts clock ticks to a duration
+func ticksToDuration(ticks uint64) time.Duration {
+	return time.Duration(ticks) * (time.Second / clockTicks)
+}
+
+// Returns the rest of the line after the fields, which must be the leading
+// fields of the line as returned by strings.Fields. The whitespace between
+// the fields and the rest is removed.
+func lineAfterFields(line string, fields []string) string {
+	for _, field := range fields {
+		line = strings.TrimLeft(line, " \t")[len(field):]
+	}
+	return strings.TrimLeft(line, " \t")
+}
+
+// Reads the names in a directory, without reading any information about
+// the files.
+func readDirNames(path string) ([]string, error) {
+	dir, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer dir.Close()
+	return dir.Readdirnames(-1)
+}
+
+// Values read from a file with "Key: Value" lines.
+type keyValues map[string]string
+
+// Reads a file with "Key: Value" lines, such as /proc/meminfo and
+// /proc/<pid>/status.
+func readKeyValueFile(path string) (keyValues, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	values := make(keyValues)
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		key, value, found := strings.Cut(scanner.Text(), ":")
+		if !found {
+			continue
+		}
+		values[key] = strings.TrimSpace(value)
+	}
+	return values, scanner.Err()
+}
+
+// Returns the value as a number. Values with a kB suffix are converted to
+// bytes. Missing or non-numeric values are returned as 0.
+func (kv keyValues) uint(key string) uint64 {
+	number, _ := kv.lookupUint(key)
+	return number
+}
+
+// Returns the value as a number like uint, and if the value exists and is
+// numeric.
+func (kv keyValues) lookupUint(key string) (uint64, bool) {
+	fields := strings.Fields(kv[key])
+	if len(fields) == 0 {
+		return 0, false
+	}
+	number, err := strconv.ParseUint(fields[0], 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	if len(fields) > 1 && fields[1] == "kB" {
+		number *= 1024
+	}
+	return number, true
+}
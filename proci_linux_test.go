@@ -0,0 +1,977 @@
+// proci unit tests for the Linux implementation using the fixture proc
+// filesystem in testdata/proc
+package proci
+
+import (
+	"errors"
+	"io/fs"
+	"net/netip"
+	"reflect"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func fixtureProci() *Proci {
+	return NewProci(
+		WithProcRoot("testdata/proc"),
+		WithEtcRoot("testdata/etc"),
+		WithCgroupRoot("testdata/cgroup"))
+}
+
+func TestFixtureGetMemoryStatus(t *testing.T) {
+	mStat, err := fixtureProci().GetMemoryStatus()
+	if err != nil {
+		t.Fatalf("GetMemoryStatus returned error: %s", err)
+	}
+	if mStat.TotalPhys != 8000000*1024 {
+		t.Errorf("Expected TotalPhys %d but it was %d", 8000000*1024, mStat.TotalPhys)
+	}
+	if mStat.AvailPhys != 2000000*1024 {
+		t.Errorf("Expected AvailPhys %d but it was %d", 2000000*1024, mStat.AvailPhys)
+	}
+	if mStat.MemoryLoad != 75 {
+		t.Errorf("Expected MemoryLoad 75 but it was %d", mStat.MemoryLoad)
+	}
+}
+
+func TestFixtureGetProcessPids(t *testing.T) {
+	pids := fixtureProci().GetProcessPids()
+	expected := []uint32{1, 2, 100, 1234}
+	if len(pids) != len(expected) {
+		t.Fatalf("Expected PIDs %v but it was %v", expected, pids)
+	}
+	for i := range expected {
+		if pids[i] != expected[i] {
+			t.Fatalf("Expected PIDs %v but it was %v", expected, pids)
+		}
+	}
+}
+
+func TestFixtureGetProcessMemoryUsage(t *testing.T) {
+	p := fixtureProci()
+	memoryUsage, err := p.GetProcessMemoryUsage(1234)
+	if err != nil {
+		t.Fatalf("GetProcessMemoryUsage returned error: %s", err)
+	}
+	if memoryUsage != 51200*1024 {
+		t.Errorf("Expected memory usage %d but it was %d", 51200*1024, memoryUsage)
+	}
+	memoryUsage, err = p.GetProcessMemoryUsage(2)
+	if err != nil {
+		t.Fatalf("GetProcessMemoryUsage for kernel thread returned error: %s", err)
+	}
+	if memoryUsage != 0 {
+		t.Errorf("Expected memory usage 0 for kernel thread but it was %d", memoryUsage)
+	}
+}
+
+func TestFixtureGetProcessPath(t *testing.T) {
+	p := fixtureProci()
+	path, err := p.GetProcessPath(100)
+	if err != nil {
+		t.Fatalf("GetProcessPath returned error: %s", err)
+	}
+	if path != "/usr/bin/bash" {
+		t.Errorf("Expected path /usr/bin/bash but it was %s", path)
+	}
+	path, err = p.GetProcessPath(2)
+	if err != nil {
+		t.Fatalf("GetProcessPath for kernel thread returned error: %s", err)
+	}
+	if path != "" {
+		t.Errorf("Expected empty path for kernel thread but it was %s", path)
+	}
+}
+
+func TestFixtureGetProcessCommandLine(t *testing.T) {
+	p := fixtureProci()
+	commandLine, err := p.GetProcessCommandLine(1234)
+	if err != nil {
+		t.Fatalf("GetProcessCommandLine returned error: %s", err)
+	}
+	if commandLine != "python3 /srv/app/worker.py --name a b" {
+		t.Errorf("Unexpected command line: %s", commandLine)
+	}
+	commandLine, err = p.GetProcessCommandLine(2)
+	if err != nil {
+		t.Fatalf("GetProcessCommandLine for kernel thread returned error: %s", err)
+	}
+	if commandLine != "" {
+		t.Errorf("Expected empty command line for kernel thread but it was %s", commandLine)
+	}
+}
+
+func TestFixtureInvalidPids(t *testing.T) {
+	p := fixtureProci()
+	_, err := p.GetProcessMemoryUsage(123456)
+	if !errors.Is(err, ErrProcessNotFound) {
+		t.Fatalf("Expected ErrProcessNotFound when providing invalid PID in GetProcessMemoryUsage but it was %v", err)
+	}
+	_, err = p.GetProcessPath(123456)
+	if !errors.Is(err, ErrProcessNotFound) {
+		t.Fatalf("Expected ErrProcessNotFound when providing invalid PID in GetProcessPath but it was %v", err)
+	}
+	_, err = p.GetProcessCommandLine(123456)
+	if !errors.Is(err, ErrProcessNotFound) {
+		t.Fatalf("Expected ErrProcessNotFound when providing invalid PID in GetProcessCommandLine but it was %v", err)
+	}
+}
+
+func TestFixtureInvalidRoot(t *testing.T) {
+	p := NewProci(WithProcRoot("testdata/nonexisting"))
+	_, err := p.GetMemoryStatus()
+	if err == nil {
+		t.Fatal("Expected error when proc root does not exist")
+	}
+	_, err = p.ListProcessPids()
+	if err == nil {
+		t.Fatal("Expected error from ListProcessPids when proc root does not exist")
+	}
+	if pids := p.GetProcessPids(); pids != nil {
+		t.Fatalf("Expected nil from GetProcessPids when proc root does not exist but it was %v", pids)
+	}
+}
+
+func TestFixtureGetProcess(t *testing.T) {
+	p := fixtureProci()
+	process, err := p.GetProcess(1234)
+	if err != nil {
+		t.Fatalf("GetProcess returned error: %s", err)
+	}
+	if process.Pid != 1234 || process.Path != "/usr/bin/python3.11" || process.Name != "python3.11" {
+		t.Errorf("Unexpected process %+v", process)
+	}
+	if process.CommandLine != "python3 /srv/app/worker.py --name a b" || process.CommandLineErr != nil {
+		t.Errorf("Unexpected command line %s (%v)", process.CommandLine, process.CommandLineErr)
+	}
+	if process.MemoryUsage != 51200*1024 || process.MemoryUsageErr != nil {
+		t.Errorf("Unexpected memory usage %d (%v)", process.MemoryUsage, process.MemoryUsageErr)
+	}
+
+	process, err = p.GetProcess(2)
+	if err != nil {
+		t.Fatalf("GetProcess for kernel thread returned error: %s", err)
+	}
+	if process.Path != "" || process.Name != "kthreadd" {
+		t.Errorf("Unexpected kernel thread process %+v", process)
+	}
+
+	_, err = p.GetProcess(123456)
+	if !errors.Is(err, ErrProcessNotFound) {
+		t.Fatalf("Expected ErrProcessNotFound when providing invalid PID in GetProcess but it was %v", err)
+	}
+}
+
+func TestFixtureSnapshot(t *testing.T) {
+	snapshot, err := fixtureProci().Snapshot()
+	if err != nil {
+		t.Fatalf("Snapshot returned error: %s", err)
+	}
+	if snapshot.MemoryStatus.TotalPhys != 8000000*1024 {
+		t.Errorf("Unexpected TotalPhys %d", snapshot.MemoryStatus.TotalPhys)
+	}
+	if len(snapshot.Processes) != 4 {
+		t.Fatalf("Expected 4 processes in snapshot but it was %d", len(snapshot.Processes))
+	}
+	python := snapshot.Processes[3]
+	if python.Pid != 1234 || python.Name != "python3.11" || python.Exited {
+		t.Errorf("Unexpected process %+v", python)
+	}
+}
+
+func TestFixtureGetProcessParentPid(t *testing.T) {
+	p := fixtureProci()
+	parentPid, err := p.GetProcessParentPid(1234)
+	if err != nil {
+		t.Fatalf("GetProcessParentPid returned error: %s", err)
+	}
+	if parentPid != 100 {
+		t.Errorf("Expected parent PID 100 but it was %d", parentPid)
+	}
+	_, err = p.GetProcessParentPid(123456)
+	if !errors.Is(err, ErrProcessNotFound) {
+		t.Fatalf("Expected ErrProcessNotFound when providing invalid PID in GetProcessParentPid but it was %v", err)
+	}
+
+	snapshot, err := p.Snapshot()
+	if err != nil {
+		t.Fatalf("Snapshot returned error: %s", err)
+	}
+	tree := BuildTree(snapshot)
+	if descendants := tree.Descendants(1); len(descendants) != 2 || descendants[0] != 100 || descendants[1] != 1234 {
+		t.Errorf("Expected descendants [100 1234] but it was %v", descendants)
+	}
+}
+
+func TestParseStat(t *testing.T) {
+	stat, err := parseStat("42 (a (b) c) S 7 42 42 0 -1 4194560 0 0 0 0 1 2 0 0 20 0 1 0 100 0 0")
+	if err != nil {
+		t.Fatalf("parseStat returned error: %s", err)
+	}
+	if stat.Name != "a (b) c" || stat.State != "S" || stat.ParentPid != 7 || stat.StartTime != 100 {
+		t.Errorf("Unexpected stat %+v", stat)
+	}
+	if _, err = parseStat("42 (name S 7"); err == nil {
+		t.Error("Expected error for invalid stat")
+	}
+}
+
+func TestFixtureGetProcessCPUTimes(t *testing.T) {
+	times, err := fixtureProci().GetProcessCPUTimes(1234)
+	if err != nil {
+		t.Fatalf("GetProcessCPUTimes returned error: %s", err)
+	}
+	if times.User != 25*time.Second || times.System != 5*time.Second {
+		t.Errorf("Unexpected CPU times %+v", times)
+	}
+}
+
+func TestTicksToDuration(t *testing.T) {
+	// 128 busy cores for 10 days
+	ticks := uint64(128 * 10 * 24 * 60 * 60 * clockTicks)
+	if duration := ticksToDuration(ticks); duration != 128*10*24*time.Hour {
+		t.Errorf("Expected %s but it was %s", 128*10*24*time.Hour, duration)
+	}
+}
+
+func TestFixtureGetProcessMemoryInfo(t *testing.T) {
+	p := fixtureProci()
+	memoryInfo, err := p.GetProcessMemoryInfo(1234)
+	if err != nil {
+		t.Fatalf("GetProcessMemoryInfo returned error: %s", err)
+	}
+	expected := MemoryInfo{
+		RSS:     51200 * 1024,
+		VMS:     300000 * 1024,
+		Shared:  (9216 + 1024) * 1024,
+		Swap:    2048 * 1024,
+		PeakRSS: 60000 * 1024,
+		PSS:     45000 * 1024,
+		USS:     (2048 + 40960) * 1024}
+	if *memoryInfo != expected {
+		t.Errorf("Expected %+v but it was %+v", expected, *memoryInfo)
+	}
+	memoryUsage, _ := p.GetProcessMemoryUsage(1234)
+	if memoryUsage != memoryInfo.RSS {
+		t.Errorf("Expected memory usage to be equal to RSS")
+	}
+
+	// No smaps_rollup for this process
+	memoryInfo, err = p.GetProcessMemoryInfo(100)
+	if err != nil {
+		t.Fatalf("GetProcessMemoryInfo returned error: %s", err)
+	}
+	if memoryInfo.RSS != 5120*1024 || memoryInfo.PSS != 0 || memoryInfo.USS != 0 {
+		t.Errorf("Unexpected memory info %+v", *memoryInfo)
+	}
+}
+
+func TestFixtureGetMemoryStatusEx(t *testing.T) {
+	mStatEx, err := fixtureProci().GetMemoryStatusEx()
+	if err != nil {
+		t.Fatalf("GetMemoryStatusEx returned error: %s", err)
+	}
+	expected := MemoryStatusEx{
+		MemoryStatus: MemoryStatus{
+			MemoryLoad: 75,
+			TotalPhys:  8000000 * 1024,
+			AvailPhys:  2000000 * 1024},
+		SwapTotal:   4000000 * 1024,
+		SwapFree:    3000000 * 1024,
+		Cached:      1500000 * 1024,
+		Buffers:     100000 * 1024,
+		Shared:      200000 * 1024,
+		Committed:   6000000 * 1024,
+		CommitLimit: 8000000 * 1024}
+	if *mStatEx != expected {
+		t.Errorf("Expected %+v but it was %+v", expected, *mStatEx)
+	}
+}
+
+func TestFixtureGetProcessArgs(t *testing.T) {
+	p := fixtureProci()
+	args, err := p.GetProcessArgs(1234)
+	if err != nil {
+		t.Fatalf("GetProcessArgs returned error: %s", err)
+	}
+	expected := []string{"python3", "/srv/app/worker.py", "--name", "a b"}
+	if !reflect.DeepEqual(args, expected) {
+		t.Errorf("Expected arguments %q but it was %q", expected, args)
+	}
+	args, err = p.GetProcessArgs(2)
+	if err != nil {
+		t.Fatalf("GetProcessArgs for kernel thread returned error: %s", err)
+	}
+	if len(args) != 0 {
+		t.Errorf("Expected no arguments for kernel thread but it was %q", args)
+	}
+	_, err = p.GetProcessArgs(123456)
+	if !errors.Is(err, ErrProcessNotFound) {
+		t.Fatalf("Expected ErrProcessNotFound when providing invalid PID in GetProcessArgs but it was %v", err)
+	}
+}
+
+func TestFixtureGetProcessEnviron(t *testing.T) {
+	p := fixtureProci()
+	environ, err := p.GetProcessEnviron(1234)
+	if err != nil {
+		t.Fatalf("GetProcessEnviron returned error: %s", err)
+	}
+	expected := map[string]string{
+		"PATH":     "/usr/bin:/bin",
+		"HOME":     "/home/worker",
+		"APP_OPTS": "--name=a b",
+		"EMPTY":    ""}
+	if !reflect.DeepEqual(environ, expected) {
+		t.Errorf("Expected environment %v but it was %v", expected, environ)
+	}
+	_, err = p.GetProcessEnviron(123456)
+	if !errors.Is(err, ErrProcessNotFound) {
+		t.Fatalf("Expected ErrProcessNotFound when providing invalid PID in GetProcessEnviron but it was %v", err)
+	}
+}
+
+func TestProcError(t *testing.T) {
+	p := fixtureProci()
+	err := p.procError(1234, "environment", &fs.PathError{Op: "open", Path: "environ", Err: fs.ErrPermission})
+	if !errors.Is(err, ErrAccessDenied) {
+		t.Errorf("Expected ErrAccessDenied but it was %v", err)
+	}
+	// The exe link of processes owned by other users
+	err = p.procError(1, "path", &fs.PathError{Op: "readlink", Path: "exe", Err: syscall.EACCES})
+	if !errors.Is(err, ErrAccessDenied) {
+		t.Errorf("Expected ErrAccessDenied for EACCES but it was %v", err)
+	}
+	err = p.procError(1234, "environment", &fs.PathError{Op: "open", Path: "environ", Err: fs.ErrNotExist})
+	if !errors.Is(err, ErrNotSupported) {
+		t.Errorf("Expected ErrNotSupported for missing file of existing process but it was %v", err)
+	}
+	err = p.procError(123456, "environment", &fs.PathError{Op: "open", Path: "environ", Err: fs.ErrNotExist})
+	if !errors.Is(err, ErrProcessNotFound) {
+		t.Errorf("Expected ErrProcessNotFound but it was %v", err)
+	}
+}
+
+func TestFixtureGetProcessCwd(t *testing.T) {
+	p := fixtureProci()
+	cwd, err := p.GetProcessCwd(1234)
+	if err != nil {
+		t.Fatalf("GetProcessCwd returned error: %s", err)
+	}
+	if cwd != "/srv/app" {
+		t.Errorf("Expected working directory /srv/app but it was %s", cwd)
+	}
+	_, err = p.GetProcessCwd(123456)
+	if !errors.Is(err, ErrProcessNotFound) {
+		t.Fatalf("Expected ErrProcessNotFound when providing invalid PID in GetProcessCwd but it was %v", err)
+	}
+}
+
+func TestFixtureGetProcessUser(t *testing.T) {
+	p := fixtureProci()
+	user, err := p.GetProcessUser(1234)
+	if err != nil {
+		t.Fatalf("GetProcessUser returned error: %s", err)
+	}
+	expected := ProcessUser{
+		UID:                1000,
+		GID:                1000,
+		EffectiveUID:       1000,
+		EffectiveGID:       1000,
+		Username:           "worker",
+		GroupName:          "worker",
+		EffectiveUsername:  "worker",
+		EffectiveGroupName: "worker"}
+	if *user != expected {
+		t.Errorf("Expected %+v but it was %+v", expected, *user)
+	}
+	_, err = p.GetProcessUser(123456)
+	if !errors.Is(err, ErrProcessNotFound) {
+		t.Fatalf("Expected ErrProcessNotFound when providing invalid PID in GetProcessUser but it was %v", err)
+	}
+
+	// Unknown names are empty
+	user, err = NewProci(WithProcRoot("testdata/proc"), WithEtcRoot("testdata/nonexisting")).GetProcessUser(1)
+	if err != nil {
+		t.Fatalf("GetProcessUser returned error: %s", err)
+	}
+	if user.UID != 0 || user.Username != "" {
+		t.Errorf("Expected UID 0 without name but it was %+v", *user)
+	}
+
+	snapshot, err := p.Snapshot()
+	if err != nil {
+		t.Fatalf("Snapshot returned error: %s", err)
+	}
+	usages := MemoryUsageByUser(snapshot)
+	if len(usages) != 2 || usages[0].Username != "worker" || usages[0].MemoryUsage != (51200+5120)*1024 {
+		t.Errorf("Unexpected memory usage by user %+v", usages)
+	}
+}
+
+func TestFixtureGetProcessStartTime(t *testing.T) {
+	p := fixtureProci()
+	startTime, err := p.GetProcessStartTime(1234)
+	if err != nil {
+		t.Fatalf("GetProcessStartTime returned error: %s", err)
+	}
+	// btime in /proc/stat plus 2000 clock ticks
+	expected := time.Unix(1700000000+20, 0)
+	if !startTime.Equal(expected) {
+		t.Errorf("Expected start time %s but it was %s", expected, startTime)
+	}
+	if _, err = p.GetProcessStartTime(123456); !errors.Is(err, ErrProcessNotFound) {
+		t.Fatalf("Expected ErrProcessNotFound when providing invalid PID in GetProcessStartTime but it was %v", err)
+	}
+
+	process, err := p.GetProcess(1234)
+	if err != nil {
+		t.Fatalf("GetProcess returned error: %s", err)
+	}
+	if process.StartTimeErr != nil || !process.StartTime.Equal(expected) {
+		t.Errorf("Unexpected start time %s, error %v", process.StartTime, process.StartTimeErr)
+	}
+	// The key is based on the clock ticks since boot, which do not change
+	// when the system clock is set
+	if key := process.Key(); key != (ProcessKey{Pid: 1234, StartTime: 2000}) {
+		t.Errorf("Unexpected process key %+v", key)
+	}
+	if key, err := p.GetProcessKey(1234); err != nil || key != process.Key() {
+		t.Errorf("Expected key %+v but it was %+v, error %v", process.Key(), key, err)
+	}
+	if _, err = p.GetProcessKey(123456); !errors.Is(err, ErrProcessNotFound) {
+		t.Fatalf("Expected ErrProcessNotFound when providing invalid PID in GetProcessKey but it was %v", err)
+	}
+
+	// Snapshots read the boot time once for all processes
+	snapshot, err := p.Snapshot()
+	if err != nil {
+		t.Fatalf("Snapshot returned error: %s", err)
+	}
+	for _, process := range snapshot.Processes {
+		if process.Pid == 1234 && (!process.StartTime.Equal(expected) || process.Key().StartTime != 2000) {
+			t.Errorf("Unexpected start time %s and key %+v in snapshot", process.StartTime, process.Key())
+		}
+	}
+}
+
+func TestFixtureGetProcessState(t *testing.T) {
+	p := fixtureProci()
+	state, err := p.GetProcessState(1234)
+	if err != nil {
+		t.Fatalf("GetProcessState returned error: %s", err)
+	}
+	if state != StateRunning {
+		t.Errorf("Expected state running but it was %s", state)
+	}
+	if state, _ = p.GetProcessState(100); state != StateSleeping {
+		t.Errorf("Expected state sleeping but it was %s", state)
+	}
+	if _, err = p.GetProcessState(123456); !errors.Is(err, ErrProcessNotFound) {
+		t.Fatalf("Expected ErrProcessNotFound when providing invalid PID in GetProcessState but it was %v", err)
+	}
+}
+
+func TestParseState(t *testing.T) {
+	tests := map[string]State{
+		"R": StateRunning,
+		"S": StateSleeping,
+		"D": StateDiskSleep,
+		"T": StateStopped,
+		"t": StateStopped,
+		"Z": StateZombie,
+		"X": StateDead,
+		"I": StateIdle,
+		"?": StateUnknown,
+	}
+	for letter, expected := range tests {
+		if state := parseState(letter); state != expected {
+			t.Errorf("Expected %s for %q but it was %s", expected, letter, state)
+		}
+	}
+}
+
+func TestFixtureGetProcessThreads(t *testing.T) {
+	p := fixtureProci()
+	threads, err := p.GetProcessThreads(1234)
+	if err != nil {
+		t.Fatalf("GetProcessThreads returned error: %s", err)
+	}
+	expected := []Thread{
+		{Tid: 1234, Name: "python3", State: StateRunning, CPUTimes: CPUTimes{User: 15 * time.Second, System: 3 * time.Second}},
+		{Tid: 1235, Name: "worker-1", State: StateSleeping, CPUTimes: CPUTimes{User: 6 * time.Second, System: time.Second}},
+		{Tid: 1236, Name: "worker-2", State: StateSleeping, CPUTimes: CPUTimes{User: 4 * time.Second, System: time.Second}},
+		{Tid: 1237, Name: "GC Thread", State: StateDiskSleep}}
+	if len(threads) != len(expected) {
+		t.Fatalf("Expected %d threads but it was %d", len(expected), len(threads))
+	}
+	for i := range expected {
+		if threads[i] != expected[i] {
+			t.Errorf("Expected %+v but it was %+v", expected[i], threads[i])
+		}
+	}
+	if _, err = p.GetProcessThreads(123456); !errors.Is(err, ErrProcessNotFound) {
+		t.Fatalf("Expected ErrProcessNotFound when providing invalid PID in GetProcessThreads but it was %v", err)
+	}
+
+	process, err := p.GetProcess(1234)
+	if err != nil {
+		t.Fatalf("GetProcess returned error: %s", err)
+	}
+	if process.ThreadCountErr != nil || process.ThreadCount != 4 {
+		t.Errorf("Expected 4 threads but it was %d, error %v", process.ThreadCount, process.ThreadCountErr)
+	}
+}
+
+func TestFixtureGetProcessOpenFiles(t *testing.T) {
+	p := fixtureProci()
+	files, err := p.GetProcessOpenFiles(1234)
+	if err != nil {
+		t.Fatalf("GetProcessOpenFiles returned error: %s", err)
+	}
+	expected := []OpenFile{
+		{Fd: 0, Path: "/dev/null", Type: FileTypeFile, Flags: 0100000},
+		{Fd: 1, Path: "pipe:[20001]", Type: FileTypePipe, Flags: 01},
+		{Fd: 2, Path: "pipe:[20001]", Type: FileTypePipe, Flags: 01},
+		{Fd: 3, Path: "/srv/app/data.db", Type: FileTypeFile, Position: 4096, Flags: 02100002},
+		{Fd: 4, Path: "socket:[30001]", Type: FileTypeSocket, Flags: 02000002},
+		{Fd: 5, Path: "socket:[30003]", Type: FileTypeSocket, Flags: 02000002},
+		{Fd: 10, Path: "anon_inode:[eventpoll]", Type: FileTypeAnonInode, Flags: 02000002}}
+	if len(files) != len(expected) {
+		t.Fatalf("Expected %d open files but it was %d", len(expected), len(files))
+	}
+	for i := range expected {
+		if files[i] != expected[i] {
+			t.Errorf("Expected %+v but it was %+v", expected[i], files[i])
+		}
+	}
+	if _, err = p.GetProcessOpenFiles(123456); !errors.Is(err, ErrProcessNotFound) {
+		t.Fatalf("Expected ErrProcessNotFound when providing invalid PID in GetProcessOpenFiles but it was %v", err)
+	}
+
+	count, err := p.GetProcessFdCount(1234)
+	if err != nil {
+		t.Fatalf("GetProcessFdCount returned error: %s", err)
+	}
+	if count != len(expected) {
+		t.Errorf("Expected %d file descriptors but it was %d", len(expected), count)
+	}
+	if _, err = p.GetProcessFdCount(123456); !errors.Is(err, ErrProcessNotFound) {
+		t.Fatalf("Expected ErrProcessNotFound when providing invalid PID in GetProcessFdCount but it was %v", err)
+	}
+}
+
+func TestFixtureGetProcessConnections(t *testing.T) {
+	p := fixtureProci()
+	connections, err := p.GetProcessConnections(1234)
+	if err != nil {
+		t.Fatalf("GetProcessConnections returned error: %s", err)
+	}
+	expected := []Connection{
+		{Protocol: "tcp", LocalAddr: netip.MustParseAddrPort("0.0.0.0:8080"), State: "LISTEN", Inode: 30001, Pid: 1234},
+		{Protocol: "unix", Path: "/run/app.sock", State: "LISTEN", Inode: 30003, Pid: 1234}}
+	if len(connections) != len(expected) {
+		t.Fatalf("Expected %d connections but it was %d", len(expected), len(connections))
+	}
+	for i := range expected {
+		if connections[i] != expected[i] {
+			t.Errorf("Expected %+v but it was %+v", expected[i], connections[i])
+		}
+	}
+	if _, err = p.GetProcessConnections(123456); !errors.Is(err, ErrProcessNotFound) {
+		t.Fatalf("Expected ErrProcessNotFound when providing invalid PID in GetProcessConnections but it was %v", err)
+	}
+}
+
+func TestFixtureConnections(t *testing.T) {
+	connections, err := fixtureProci().Connections()
+	if err != nil {
+		t.Fatalf("Connections returned error: %s", err)
+	}
+	expected := []Connection{
+		{Protocol: "tcp", LocalAddr: netip.MustParseAddrPort("0.0.0.0:8080"), State: "LISTEN", Inode: 30001, Pid: 1234},
+		{Protocol: "tcp", LocalAddr: netip.MustParseAddrPort("127.0.0.1:54321"), RemoteAddr: netip.MustParseAddrPort("127.0.0.1:5432"), State: "ESTABLISHED", Inode: 40001},
+		{Protocol: "tcp6", LocalAddr: netip.MustParseAddrPort("[::]:22"), State: "LISTEN", Inode: 40002},
+		{Protocol: "udp", LocalAddr: netip.MustParseAddrPort("0.0.0.0:68"), State: "UNCONNECTED", Inode: 40004},
+		{Protocol: "unix", Path: "/run/app.sock", State: "LISTEN", Inode: 30003, Pid: 1234},
+		{Protocol: "unix", State: "CONNECTED", Inode: 40003}}
+	if len(connections) != len(expected) {
+		t.Fatalf("Expected %d connections but it was %d", len(expected), len(connections))
+	}
+	for i := range expected {
+		if connections[i] != expected[i] {
+			t.Errorf("Expected %+v but it was %+v", expected[i], connections[i])
+		}
+	}
+}
+
+func TestParseInetAddress(t *testing.T) {
+	addr, err := parseInetAddress("00000000000000000000000001000000:1F90")
+	if err != nil {
+		t.Fatalf("parseInetAddress returned error: %s", err)
+	}
+	if addr != netip.MustParseAddrPort("[::1]:8080") {
+		t.Errorf("Expected [::1]:8080 but it was %s", addr)
+	}
+	for _, invalid := range []string{"0100007F", "0100007:1F90", "0100007F:XYZ"} {
+		if _, err = parseInetAddress(invalid); err == nil {
+			t.Errorf("Expected error for %q", invalid)
+		}
+	}
+}
+
+func TestFixtureGetProcessIOCounters(t *testing.T) {
+	p := fixtureProci()
+	counters, err := p.GetProcessIOCounters(1234)
+	if err != nil {
+		t.Fatalf("GetProcessIOCounters returned error: %s", err)
+	}
+	expected := IOCounters{
+		ReadCount:           300,
+		WriteCount:          150,
+		ReadChars:           1048576,
+		WriteChars:          524288,
+		ReadBytes:           409600,
+		WriteBytes:          204800,
+		CancelledWriteBytes: 4096}
+	if *counters != expected {
+		t.Errorf("Expected %+v but it was %+v", expected, *counters)
+	}
+	if _, err = p.GetProcessIOCounters(123456); !errors.Is(err, ErrProcessNotFound) {
+		t.Fatalf("Expected ErrProcessNotFound when providing invalid PID in GetProcessIOCounters but it was %v", err)
+	}
+	if _, err = p.GetProcessIOCounters(100); !errors.Is(err, ErrNotSupported) {
+		t.Fatalf("Expected ErrNotSupported when io file is missing but it was %v", err)
+	}
+}
+
+func TestFixtureGetProcessLimits(t *testing.T) {
+	p := fixtureProci()
+	limits, err := p.GetProcessLimits(1234)
+	if err != nil {
+		t.Fatalf("GetProcessLimits returned error: %s", err)
+	}
+	if len(limits) != 16 {
+		t.Errorf("Expected 16 limits but it was %d", len(limits))
+	}
+	expected := map[string]Limit{
+		LimitOpenFiles:         {Soft: 8, Hard: 524288, Unit: "files"},
+		LimitStackSize:         {Soft: 8388608, Hard: LimitUnlimited, Unit: "bytes"},
+		LimitCoreFileSize:      {Soft: 0, Hard: LimitUnlimited, Unit: "bytes"},
+		"Max cpu time":         {Soft: LimitUnlimited, Hard: LimitUnlimited, Unit: "seconds"},
+		"Max nice priority":    {Soft: 0, Hard: 0},
+		"Max realtime timeout": {Soft: LimitUnlimited, Hard: LimitUnlimited, Unit: "us"}}
+	for resource, limit := range expected {
+		if limits[resource] != limit {
+			t.Errorf("Expected %+v for %q but it was %+v", limit, resource, limits[resource])
+		}
+	}
+	if _, err = p.GetProcessLimits(123456); !errors.Is(err, ErrProcessNotFound) {
+		t.Fatalf("Expected ErrProcessNotFound when providing invalid PID in GetProcessLimits but it was %v", err)
+	}
+
+	usages, err := GetLimitUsage(p, 1234)
+	if err != nil {
+		t.Fatalf("GetLimitUsage returned error: %s", err)
+	}
+	if len(usages) != 3 {
+		t.Fatalf("Expected 3 limit usages but it was %d", len(usages))
+	}
+	if usages[0].Resource != LimitOpenFiles || usages[0].Used != 7 || usages[0].Percent != 87.5 {
+		t.Errorf("Unexpected open files usage %+v", usages[0])
+	}
+	if usages[1].Resource != LimitAddressSpace || usages[1].Percent != 50 {
+		t.Errorf("Unexpected address space usage %+v", usages[1])
+	}
+}
+
+func TestParseLimits(t *testing.T) {
+	if _, err := parseLimits("Limit Units\n"); err == nil {
+		t.Error("Expected error for invalid header")
+	}
+	data := "Limit                     Soft Limit           Hard Limit           Units     \n" +
+		"Max open files            many                 524288               files     \n"
+	if _, err := parseLimits(data); err == nil {
+		t.Error("Expected error for invalid limit")
+	}
+}
+
+func TestFixtureGetProcessCgroups(t *testing.T) {
+	p := fixtureProci()
+	cgroups, err := p.GetProcessCgroups(100)
+	if err != nil {
+		t.Fatalf("GetProcessCgroups returned error: %s", err)
+	}
+	expected := []Cgroup{
+		{HierarchyID: 12, Controllers: []string{"pids"}, Path: "/user.slice/user-1000.slice/session-2.scope"},
+		{HierarchyID: 11, Controllers: []string{"memory"}, Path: "/user.slice/user-1000.slice/session-2.scope"},
+		{HierarchyID: 4, Controllers: []string{"cpu", "cpuacct"}, Path: "/user.slice"},
+		{HierarchyID: 1, Controllers: []string{"name=systemd"}, Path: "/user.slice/user-1000.slice/session-2.scope"}}
+	if !reflect.DeepEqual(cgroups, expected) {
+		t.Errorf("Expected %+v but it was %+v", expected, cgroups)
+	}
+	if _, err = p.GetProcessCgroups(123456); !errors.Is(err, ErrProcessNotFound) {
+		t.Fatalf("Expected ErrProcessNotFound when providing invalid PID in GetProcessCgroups but it was %v", err)
+	}
+
+	snapshot, err := p.Snapshot()
+	if err != nil {
+		t.Fatalf("Snapshot returned error: %s", err)
+	}
+	usages := MemoryUsageByCgroup(snapshot)
+	if len(usages) != 4 {
+		t.Fatalf("Expected 4 cgroups but it was %d", len(usages))
+	}
+	container := usages[0].Container
+	if container == nil || container.Runtime != "docker" || !reflect.DeepEqual(usages[0].Pids, []uint32{1234}) {
+		t.Errorf("Expected docker container with PID 1234 but it was %+v", usages[0])
+	}
+	// PID 100 only has cgroup v1 hierarchies, so the memory controller is used
+	if usages[2].Path != "/user.slice/user-1000.slice/session-2.scope" || usages[2].Container != nil {
+		t.Errorf("Expected session cgroup without container but it was %+v", usages[2])
+	}
+}
+
+func TestParseCgroups(t *testing.T) {
+	cgroups, err := parseCgroups("0::/a:b\n")
+	if err != nil {
+		t.Fatalf("parseCgroups returned error: %s", err)
+	}
+	if len(cgroups) != 1 || cgroups[0].Path != "/a:b" || cgroups[0].Controllers != nil {
+		t.Errorf("Unexpected cgroups %+v", cgroups)
+	}
+	for _, invalid := range []string{"0:/", "x::/"} {
+		if _, err = parseCgroups(invalid); err == nil {
+			t.Errorf("Expected error for %q", invalid)
+		}
+	}
+}
+
+func TestFixtureMemoryUsageByCgroupHybrid(t *testing.T) {
+	// On hybrid hosts both containers share the unified path, which has no
+	// controllers, so the cgroup v1 memory controller is used
+	p := NewProci(WithProcRoot("testdata/hybrid/proc"), WithCgroupRoot("testdata/cgroup"))
+	snapshot, err := p.Snapshot()
+	if err != nil {
+		t.Fatalf("Snapshot returned error: %s", err)
+	}
+	usages := MemoryUsageByCgroup(snapshot)
+	if len(usages) != 2 {
+		t.Fatalf("Expected 2 cgroups but it was %+v", usages)
+	}
+	for i, id := range []string{"aaaa0000", "bbbb1111"} {
+		usage := usages[i]
+		if usage.Path != "/docker/"+strings.Repeat(id, 8) || usage.Container == nil || usage.Container.ID != strings.Repeat(id, 8) ||
+			len(usage.Pids) != 1 || usage.MemoryUsage != 5120*1024 {
+			t.Errorf("Unexpected cgroup memory usage %+v", usage)
+		}
+	}
+}
+
+func TestFixtureCgroupStats(t *testing.T) {
+	p := fixtureProci()
+	cgroups, err := p.GetProcessCgroups(1234)
+	if err != nil {
+		t.Fatalf("GetProcessCgroups returned error: %s", err)
+	}
+	stats, err := p.CgroupStats(cgroups[0].Path)
+	if err != nil {
+		t.Fatalf("CgroupStats returned error: %s", err)
+	}
+	expected := CgroupResourceStats{
+		MemoryCurrent: 73400320,
+		MemoryMax:     134217728,
+		MemoryStat:    map[string]uint64{"anon": 52428800, "file": 18874368, "kernel": 2097152, "sock": 0, "shmem": 1048576},
+		CPUUsage:      30 * time.Second,
+		CPUUser:       25 * time.Second,
+		CPUSystem:     5 * time.Second,
+		CPUThrottled:  1500 * time.Millisecond,
+		IO: map[string]CgroupIOStat{
+			"8:0":   {ReadBytes: 409600, WriteBytes: 204800, ReadIOs: 100, WriteIOs: 50},
+			"253:0": {ReadBytes: 4096, ReadIOs: 1}},
+		PidsCurrent: 4}
+	if !reflect.DeepEqual(*stats, expected) {
+		t.Errorf("Expected %+v but it was %+v", expected, *stats)
+	}
+
+	// Controllers that are not enabled are left out
+	stats, err = p.CgroupStats("/")
+	if err != nil {
+		t.Fatalf("CgroupStats returned error: %s", err)
+	}
+	if stats.CPUUsage != 5*time.Second || stats.MemoryCurrent != 0 || stats.MemoryMax != LimitUnlimited {
+		t.Errorf("Unexpected root cgroup stats %+v", *stats)
+	}
+	if _, err = p.CgroupStats("/nonexisting.slice"); err == nil {
+		t.Error("Expected error for non existing cgroup")
+	}
+	if _, err = p.CgroupStats("/../../proc"); err == nil {
+		t.Error("Expected error for cgroup outside of the cgroup root")
+	}
+	// Hybrid hosts only have cgroup v1 hierarchies at the default root
+	hybrid := NewProci(WithProcRoot("testdata/proc"), WithCgroupRoot("testdata/proc"))
+	if _, err = hybrid.CgroupStats("/"); !errors.Is(err, ErrNotSupported) {
+		t.Errorf("Expected ErrNotSupported without cgroup v2 filesystem but it was %v", err)
+	}
+
+	snapshot, err := p.Snapshot()
+	if err != nil {
+		t.Fatalf("Snapshot returned error: %s", err)
+	}
+	for _, process := range snapshot.Processes {
+		switch process.Pid {
+		case 1:
+			if process.CgroupStatsErr != nil || process.CgroupStats.MemoryMax != LimitUnlimited {
+				t.Errorf("Unexpected cgroup stats %+v of PID 1, error %v", process.CgroupStats, process.CgroupStatsErr)
+			}
+		case 100:
+			// Only in cgroup v1 hierarchies
+			if process.CgroupStatsErr != nil || process.CgroupStats != nil {
+				t.Errorf("Expected no cgroup stats for PID 100 but it was %+v, error %v", process.CgroupStats, process.CgroupStatsErr)
+			}
+		case 1234:
+			if process.CgroupStatsErr != nil || process.CgroupStats.MemoryMax != 134217728 {
+				t.Errorf("Unexpected cgroup stats %+v of PID 1234, error %v", process.CgroupStats, process.CgroupStatsErr)
+			}
+		}
+	}
+}
+
+func TestParseCgroupFiles(t *testing.T) {
+	if _, err := parseCgroupValue("many"); err == nil {
+		t.Error("Expected error for invalid value")
+	}
+	if _, err := parseFlatKeyed("anon many"); err == nil {
+		t.Error("Expected error for invalid flat keyed value")
+	}
+	if _, err := parseIOStat("8:0 rbytes=many"); err == nil {
+		t.Error("Expected error for invalid io.stat value")
+	}
+}
+
+func TestFixtureGetProcessNamespaces(t *testing.T) {
+	p := fixtureProci()
+	namespaces, err := p.GetProcessNamespaces(1234)
+	if err != nil {
+		t.Fatalf("GetProcessNamespaces returned error: %s", err)
+	}
+	// The time namespace is missing, as on kernels older than 5.6
+	expected := Namespaces{
+		Mnt:    4026532301,
+		Pid:    4026532304,
+		Net:    4026532306,
+		UTS:    4026532302,
+		IPC:    4026532303,
+		User:   4026531837,
+		Cgroup: 4026532305}
+	if *namespaces != expected {
+		t.Errorf("Expected %+v but it was %+v", expected, *namespaces)
+	}
+	if _, err = p.GetProcessNamespaces(123456); !errors.Is(err, ErrProcessNotFound) {
+		t.Fatalf("Expected ErrProcessNotFound when providing invalid PID in GetProcessNamespaces but it was %v", err)
+	}
+	if _, err = p.GetProcessNamespaces(2); !errors.Is(err, ErrNotSupported) {
+		t.Fatalf("Expected ErrNotSupported when the ns directory is missing but it was %v", err)
+	}
+
+	snapshot, err := p.Snapshot()
+	if err != nil {
+		t.Fatalf("Snapshot returned error: %s", err)
+	}
+	if pids := SharingNamespace(snapshot, 100, NamespaceNet); !reflect.DeepEqual(pids, []uint32{1, 100}) {
+		t.Errorf("Expected PID 1 and 100 in the same network namespace but it was %v", pids)
+	}
+	if pids := SharingNamespace(snapshot, 1234, NamespaceUser); !reflect.DeepEqual(pids, []uint32{1, 100, 1234}) {
+		t.Errorf("Expected all processes in the same user namespace but it was %v", pids)
+	}
+}
+
+func TestNamespaceInode(t *testing.T) {
+	inode, err := namespaceInode("net", "net:[4026531840]")
+	if err != nil || inode != 4026531840 {
+		t.Errorf("Expected inode 4026531840 but it was %d, error %v", inode, err)
+	}
+	for _, invalid := range []string{"pid:[4026531840]", "net:[4026531840", "net:[x]"} {
+		if _, err = namespaceInode("net", invalid); err == nil {
+			t.Errorf("Expected error for %q", invalid)
+		}
+	}
+}
+
+func TestFixtureGetProcessMemoryMaps(t *testing.T) {
+	p := fixtureProci()
+	maps, err := p.GetProcessMemoryMaps(1234)
+	if err != nil {
+		t.Fatalf("GetProcessMemoryMaps returned error: %s", err)
+	}
+	if len(maps) != 8 {
+		t.Fatalf("Expected 8 memory maps but it was %d", len(maps))
+	}
+	expected := MemoryMap{
+		StartAddr:   0x55d0ca000000,
+		EndAddr:     0x55d0cc000000,
+		Permissions: "rw-p",
+		Device:      "00:00",
+		Path:        "[heap]",
+		Size:        32 * 1024 * 1024,
+		RSS:         30720 * 1024,
+		PSS:         30720 * 1024,
+		Swap:        1024 * 1024,
+		Anonymous:   30720 * 1024}
+	if maps[2] != expected {
+		t.Errorf("Expected %+v but it was %+v", expected, maps[2])
+	}
+	if maps[1].Offset != 0x21000 || maps[1].Inode != 1312345 || maps[1].Path != "/usr/bin/python3.11" {
+		t.Errorf("Unexpected file mapping %+v", maps[1])
+	}
+	if maps[3].Path != "" || maps[6].Path != "/dev/shm/app cache (deleted)" {
+		t.Errorf("Unexpected paths %q and %q", maps[3].Path, maps[6].Path)
+	}
+
+	usages := AggregateMemoryMaps(maps)
+	if len(usages) != 6 {
+		t.Fatalf("Expected 6 paths but it was %d", len(usages))
+	}
+	if usages[0].Path != "[heap]" || usages[2].Path != "/usr/bin/python3.11" || usages[2].Regions != 2 || usages[2].PSS != 1090*1024 {
+		t.Errorf("Unexpected aggregated memory maps %+v", usages)
+	}
+
+	// Only maps, as on kernels without CONFIG_PROC_PAGE_MONITOR
+	maps, err = p.GetProcessMemoryMaps(100)
+	if err != nil {
+		t.Fatalf("GetProcessMemoryMaps returned error: %s", err)
+	}
+	if len(maps) != 3 || maps[2].Path != "[heap]" || maps[2].Size != 32*1024*1024 || maps[2].RSS != 0 {
+		t.Errorf("Unexpected memory maps %+v", maps)
+	}
+
+	if _, err = p.GetProcessMemoryMaps(123456); !errors.Is(err, ErrProcessNotFound) {
+		t.Fatalf("Expected ErrProcessNotFound when providing invalid PID in GetProcessMemoryMaps but it was %v", err)
+	}
+}
+
+func TestParseUnixConnections(t *testing.T) {
+	data := "Num       RefCount Protocol Flags    Type St Inode Path\n" +
+		"0000000000000000: 00000002 00000000 00010000 0001 01 30003 /run/my\tapp  1.sock\n"
+	connections, err := parseUnixConnections(data)
+	if err != nil {
+		t.Fatalf("parseUnixConnections returned error: %s", err)
+	}
+	if len(connections) != 1 || connections[0].Path != "/run/my\tapp  1.sock" {
+		t.Errorf("Unexpected connections %+v", connections)
+	}
+}
+
+func TestParseMapsLine(t *testing.T) {
+	for _, invalid := range []string{
+		"55d0c8a00000 r--p 00000000 08:01 1312345",
+		"55d0c8a00000-55d0c8a21000 r--p 00000000 08:01",
+		"55d0c8a00000-55d0c8a21000 r--p xyz 08:01 1312345",
+		"55d0c8a21000-55d0c8a00000 r--p 00000000 08:01 1312345"} {
+		if _, err := parseMapsLine(invalid); err == nil {
+			t.Errorf("Expected error for %q", invalid)
+		}
+	}
+}
@@ -2,7 +2,10 @@
 package proci
 
 import (
+	"errors"
+	"os"
 	"testing"
+	"time"
 )
 
 func TestGetMemoryStatus(t *testing.T) {
@@ -37,7 +40,7 @@ func TestGetProcessMemoryUsage(t *testing.T) {
 	if len(pids) < 10 {
 		t.Errorf("Number of pids very low. Number of pids: %d", len(pids))
 	}
-	pid := pids[10] // Pick a random process
+	pid := uint32(os.Getpid()) // Pick this test process
 	t.Log("Get memory for process with pid:", pid)
 	memoryUsage, err := GetProcessMemoryUsage(pid)
 	if err != nil {
@@ -54,7 +57,7 @@ func TestGetProcessPath(t *testing.T) {
 	if len(pids) < 10 {
 		t.Errorf("Number of pids very low. Number of pids: %d", len(pids))
 	}
-	pid := pids[10] // Pick a random process
+	pid := uint32(os.Getpid()) // Pick this test process
 	t.Log("Get path for process with pid:", pid)
 	path, err := GetProcessPath(pid)
 	if err != nil {
@@ -66,16 +69,13 @@ func TestGetProcessPath(t *testing.T) {
 	}
 }
 
-// This test requires that you are running as administrator. Also,
-// since we are picking a random process we might pick a process
-// that we cannot access even if administrative access rights are
-// used.
+// This test requires that you are running as administrator.
 func TestGetProcessCommandLine(t *testing.T) {
 	pids := GetProcessPids()
 	if len(pids) < 10 {
 		t.Errorf("Number of pids very low. Number of pids: %d", len(pids))
 	}
-	pid := pids[10] // Pick a random process
+	pid := uint32(os.Getpid()) // Pick this test process
 	t.Log("Get command line for process with pid:", pid)
 	commandLine, err := GetProcessCommandLine(pid)
 	if err != nil {
@@ -101,10 +101,14 @@ func TestGetAllProcessInfo(t *testing.T) {
 			continue
 		}
 		path, patherr := GetProcessPath(pid)
-		if patherr != nil {
+		if errors.Is(patherr, ErrAccessDenied) {
+			// Not an error. Expected for processes of other users.
+			if doLog {
+				t.Log("  Unable to read path for PID", pid, " error: ", patherr)
+			}
+		} else if patherr != nil {
 			t.Fatalf("GetProcessPath for PID %d returned error: %s", pid, patherr)
-		}
-		if doLog {
+		} else if doLog {
 			t.Log("  Path:", path)
 		}
 		commandLine, cmderr := GetProcessCommandLine(pid)
@@ -160,10 +164,14 @@ func TestInterface(t *testing.T) {
 			continue
 		}
 		path, patherr := prociInterface.GetProcessPath(pid)
-		if patherr != nil {
+		if errors.Is(patherr, ErrAccessDenied) {
+			// Not an error. Expected for processes of other users.
+			if doLog {
+				t.Log("  Unable to read path for PID", pid, " error: ", patherr)
+			}
+		} else if patherr != nil {
 			t.Fatalf("GetProcessPath for PID %d returned error: %s", pid, patherr)
-		}
-		if doLog {
+		} else if doLog {
 			t.Log("  Path:", path)
 		}
 		commandLine, cmderr := prociInterface.GetProcessCommandLine(pid)
@@ -203,3 +211,198 @@ func TestInterface(t *testing.T) {
 		t.Errorf("Available memory cannot be 0")
 	}
 }
+
+func TestGetProcess(t *testing.T) {
+	pid := uint32(os.Getpid()) // Pick this test process
+	process, err := GetProcess(pid)
+	if err != nil {
+		t.Fatalf("GetProcess returned error: %s", err)
+	}
+	t.Log("Process with pid", pid, "name:", process.Name, "path:", process.Path)
+	if process.Path == "" || process.PathErr != nil {
+		t.Errorf("Process path cannot be empty string. Error: %v", process.PathErr)
+	}
+	if process.Name == "" {
+		t.Errorf("Process name cannot be empty string.")
+	}
+	if process.MemoryUsage == 0 || process.MemoryUsageErr != nil {
+		t.Errorf("Process memory usage cannot be 0 bytes. Error: %v", process.MemoryUsageErr)
+	}
+}
+
+func TestSnapshotExited(t *testing.T) {
+	snapshot, err := Snapshot()
+	if err != nil {
+		t.Fatalf("Snapshot returned error: %s", err)
+	}
+	for _, process := range snapshot.Processes {
+		// The idle process on Windows exists but cannot be opened
+		if process.Pid == 0 && process.Exited {
+			t.Errorf("Process 0 cannot be marked as exited. Error: %v", process.PathErr)
+		}
+	}
+}
+
+func TestGetProcessCPUTimes(t *testing.T) {
+	pid := uint32(os.Getpid()) // Pick this test process
+	times, err := GetProcessCPUTimes(pid)
+	if err != nil {
+		t.Fatalf("GetProcessCPUTimes returned error: %s", err)
+	}
+	t.Log("Process with pid", pid, "user time:", times.User, "system time:", times.System)
+	if times.User < 0 || times.System < 0 {
+		t.Errorf("CPU times cannot be negative")
+	}
+
+	// Use some CPU time before reading the times again
+	for start := time.Now(); time.Since(start) < 50*time.Millisecond; {
+	}
+	later, err := GetProcessCPUTimes(pid)
+	if err != nil {
+		t.Fatalf("GetProcessCPUTimes returned error: %s", err)
+	}
+	if later.User < times.User || later.System < times.System {
+		t.Errorf("CPU times cannot decrease, from %+v to %+v", *times, *later)
+	}
+}
+
+func TestGetProcessMemoryInfo(t *testing.T) {
+	pid := uint32(os.Getpid()) // Pick this test process
+	memoryInfo, err := GetProcessMemoryInfo(pid)
+	if err != nil {
+		t.Fatalf("GetProcessMemoryInfo returned error: %s", err)
+	}
+	t.Logf("Process with pid %d memory info: %+v", pid, *memoryInfo)
+	if memoryInfo.RSS == 0 {
+		t.Errorf("Process RSS cannot be 0 bytes")
+	}
+	if memoryInfo.PeakRSS < memoryInfo.RSS {
+		t.Errorf("Process peak RSS cannot be lower than RSS")
+	}
+}
+
+func TestGetMemoryStatusEx(t *testing.T) {
+	mStatEx, err := GetMemoryStatusEx()
+	if err != nil {
+		t.Fatalf("GetMemoryStatusEx returned error: %s", err)
+	}
+	t.Logf("Memory status: %+v", *mStatEx)
+	if mStatEx.TotalPhys == 0 {
+		t.Errorf("Total memory cannot be 0")
+	}
+	if mStatEx.CommitLimit == 0 {
+		t.Errorf("Commit limit cannot be 0")
+	}
+}
+
+// This test requires that you are running as administrator.
+func TestGetProcessArgs(t *testing.T) {
+	pid := uint32(os.Getpid()) // Pick this test process
+	args, err := GetProcessArgs(pid)
+	if err != nil {
+		t.Fatalf("GetProcessArgs returned error: %s", err)
+	}
+	t.Logf("Process with pid %d arguments: %q", pid, args)
+	if len(args) != len(os.Args) {
+		t.Errorf("Expected %d arguments but it was %d", len(os.Args), len(args))
+	}
+}
+
+// This test requires that you are running as administrator.
+func TestGetProcessEnviron(t *testing.T) {
+	pid := uint32(os.Getpid()) // Pick this test process
+	environ, err := GetProcessEnviron(pid)
+	if err != nil {
+		t.Fatalf("GetProcessEnviron returned error: %s", err)
+	}
+	t.Log("Process with pid", pid, "has", len(environ), "environment variables")
+	if len(environ) == 0 {
+		t.Errorf("Process environment cannot be empty")
+	}
+}
+
+// This test requires that you are running as administrator.
+func TestGetProcessCwd(t *testing.T) {
+	pid := uint32(os.Getpid()) // Pick this test process
+	cwd, err := GetProcessCwd(pid)
+	if err != nil {
+		t.Fatalf("GetProcessCwd returned error: %s", err)
+	}
+	expected, _ := os.Getwd()
+	t.Log("Process with pid", pid, "working directory:", cwd)
+	// Getwd may return a path with symlinks, while the working directory of
+	// the process is resolved, so compare the directories instead of paths
+	cwdInfo, err := os.Stat(cwd)
+	if err != nil {
+		t.Fatalf("Unable to stat working directory %s: %s", cwd, err)
+	}
+	expectedInfo, err := os.Stat(expected)
+	if err != nil {
+		t.Fatalf("Unable to stat working directory %s: %s", expected, err)
+	}
+	if !os.SameFile(cwdInfo, expectedInfo) {
+		t.Errorf("Expected working directory %s but it was %s", expected, cwd)
+	}
+}
+
+func TestGetProcessStartTime(t *testing.T) {
+	pid := uint32(os.Getpid()) // Pick this test process
+	startTime, err := GetProcessStartTime(pid)
+	if err != nil {
+		t.Fatalf("GetProcessStartTime returned error: %s", err)
+	}
+	if startTime.After(time.Now()) {
+		t.Errorf("Expected start time in the past but it was %s", startTime)
+	}
+	t.Log("Process with pid", pid, "has been running for", time.Since(startTime))
+}
+
+func TestGetProcessThreads(t *testing.T) {
+	pid := uint32(os.Getpid()) // Pick this test process
+	threads, err := GetProcessThreads(pid)
+	if err != nil {
+		t.Fatalf("GetProcessThreads returned error: %s", err)
+	}
+	if len(threads) == 0 {
+		t.Fatal("Expected at least one thread")
+	}
+	t.Log("Process with pid", pid, "has", len(threads), "threads")
+}
+
+func TestGetProcessFdCount(t *testing.T) {
+	pid := uint32(os.Getpid()) // Pick this test process
+	count, err := GetProcessFdCount(pid)
+	if err != nil {
+		t.Fatalf("GetProcessFdCount returned error: %s", err)
+	}
+	if count == 0 {
+		t.Fatal("Expected at least one open file descriptor")
+	}
+	t.Log("Process with pid", pid, "has", count, "open file descriptors")
+}
+
+func TestGetProcessIOCounters(t *testing.T) {
+	pid := uint32(os.Getpid()) // Pick this test process
+	counters, err := GetProcessIOCounters(pid)
+	if err != nil {
+		t.Fatalf("GetProcessIOCounters returned error: %s", err)
+	}
+	t.Log("Process with pid", pid, "read bytes:", counters.ReadChars, "written bytes:", counters.WriteChars)
+
+	// Write a file before reading the counters again
+	if err = os.WriteFile(t.TempDir()+"/io", make([]byte, 4096), 0600); err != nil {
+		t.Fatalf("Unable to write file: %s", err)
+	}
+	later, err := GetProcessIOCounters(pid)
+	if err != nil {
+		t.Fatalf("GetProcessIOCounters returned error: %s", err)
+	}
+	if later.ReadCount < counters.ReadCount || later.WriteCount < counters.WriteCount ||
+		later.ReadChars < counters.ReadChars || later.WriteChars < counters.WriteChars ||
+		later.ReadBytes < counters.ReadBytes || later.WriteBytes < counters.WriteBytes {
+		t.Errorf("I/O counters cannot decrease, from %+v to %+v", *counters, *later)
+	}
+	if later.WriteChars < counters.WriteChars+4096 {
+		t.Errorf("Expected at least 4096 more written bytes but it was %d", later.WriteChars-counters.WriteChars)
+	}
+}
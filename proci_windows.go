@@ -2,8 +2,14 @@
 package proci
 
 import (
+	"errors"
 	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
 	"syscall"
+	"time"
 	"unsafe"
 )
 
@@ -19,12 +25,23 @@ var (
 	ntDll    = syscall.NewLazyDLL("Ntdll.dll")
 	advapi32 = syscall.NewLazyDLL("Advapi32.dll")
 
-	globalMemoryStatusEx = kernel32.NewProc("GlobalMemoryStatusEx")
-	getCurrentProcess    = kernel32.NewProc("GetCurrentProcess")
-	openProcess          = kernel32.NewProc("OpenProcess")
-	closeHandle          = kernel32.NewProc("CloseHandle")
-	getLastError         = kernel32.NewProc("GetLastError")
-	readProcessMemory    = kernel32.NewProc("ReadProcessMemory")
+	globalMemoryStatusEx  = kernel32.NewProc("GlobalMemoryStatusEx")
+	getCurrentProcess     = kernel32.NewProc("GetCurrentProcess")
+	openProcess           = kernel32.NewProc("OpenProcess")
+	closeHandle           = kernel32.NewProc("CloseHandle")
+	getLastError          = kernel32.NewProc("GetLastError")
+	readProcessMemory     = kernel32.NewProc("ReadProcessMemory")
+	getProcessTimes       = kernel32.NewProc("GetProcessTimes")
+	getProcessHandleCount = kernel32.NewProc("GetProcessHandleCount")
+	getProcessIoCounters  = kernel32.NewProc("GetProcessIoCounters")
+	openThread            = kernel32.NewProc("OpenThread")
+	getThreadTimes        = kernel32.NewProc("GetThreadTimes")
+
+	createToolhelp32Snapshot = kernel32.NewProc("CreateToolhelp32Snapshot")
+	thread32First            = kernel32.NewProc("Thread32First")
+	thread32Next             = kernel32.NewProc("Thread32Next")
+	process32First           = kernel32.NewProc("Process32FirstW")
+	process32Next            = kernel32.NewProc("Process32NextW")
 
 	enumProcesses           = psapi.NewProc("EnumProcesses")
 	getProcessMemoryInfo    = psapi.NewProc("GetProcessMemoryInfo")
@@ -51,6 +68,11 @@ type winSizeT uint64
 type winPVoid uint64
 type winPointer uint64 // Generic for all kinds of pointers
 
+type winFileTime struct {
+	LowDateTime  winDWord
+	HighDateTime winDWord
+}
+
 type winUnicodeString struct {
 	Length        winUShort
 	MaximumLength winUShort
@@ -58,7 +80,7 @@ type winUnicodeString struct {
 }
 
 //////////////////////////////////////////////////////////////////////////////
-// Initialization - set priviledges to allow memory write of other processes
+// Priviledges - set priviledges to allow memory read of other processes
 
 type winLUID struct {
 	LowPart  winDWord
@@ -77,12 +99,26 @@ type winTokenPriviledges struct {
 	Privileges     [1]winLUIDAndAttributes
 }
 
+// Result of enabling the debug priviledge. Only done once.
+var (
+	debugPriviledgeOnce sync.Once
+	debugPriviledgeErr  error
+)
+
 // Give current process priviledges to read in other processes memory
-// i.e. call the readProcessMemory function. All errors lead to panic.
-func init() {
+// i.e. call the readProcessMemory function. This is done the first time
+// the function is called, later calls returns the result of the first call.
+func enableDebugPriviledge() error {
+	debugPriviledgeOnce.Do(func() {
+		debugPriviledgeErr = adjustDebugPriviledge()
+	})
+	return debugPriviledgeErr
+}
+
+func adjustDebugPriviledge() error {
 	handle, _, err := getCurrentProcess.Call()
 	if handle == 0 {
-		panic(fmt.Sprintf("Unable to open current process. Reason: %s\n", err))
+		return fmt.Errorf("unable to open current process. Reason: %s", err)
 	}
 
 	var token uintptr
@@ -91,8 +127,9 @@ func init() {
 		uintptr(0x0028),
 		uintptr(unsafe.Pointer(&token)))
 	if ret2 == 0 {
-		panic(fmt.Sprintf("Unable to open token. Reason: %s\n", err2))
+		return fmt.Errorf("unable to open token. Reason: %s", err2)
 	}
+	defer closeHandle.Call(token)
 
 	tokenPriviledges := winTokenPriviledges{PrivilegeCount: 1}
 	lpName := syscall.StringToUTF16("SeDebugPrivilege")
@@ -101,7 +138,7 @@ func init() {
 		uintptr(unsafe.Pointer(&lpName[0])),
 		uintptr(unsafe.Pointer(&tokenPriviledges.Privileges[0].Luid)))
 	if ret3 == 0 {
-		panic(fmt.Sprintf("Unable to lookup priviledges. Reason: %s\n", err3))
+		return fmt.Errorf("unable to lookup priviledges. Reason: %s", err3)
 	}
 
 	tokenPriviledges.Privileges[0].Attributes = 0x00000002 // SE_PRIVILEGE_ENABLED
@@ -114,8 +151,9 @@ func init() {
 		0,
 		0)
 	if ret4 == 0 {
-		panic(fmt.Sprintf("Unable to adjust token priviledges. Reason: %s\n", err4))
+		return fmt.Errorf("unable to adjust token priviledges. Reason: %s", err4)
 	}
+	return nil
 }
 
 //////////////////////////////////////////////////////////////////////////////
@@ -134,44 +172,61 @@ type winMemoryStatusEx struct {
 }
 
 // getMemoryStatus implements GetMemoryStatus.
-func getMemoryStatus() (*MemoryStatus, error) {
+func (s Proci) getMemoryStatus() (*MemoryStatus, error) {
+	memoryStatusEx, err := s.getMemoryStatusEx()
+	if err != nil {
+		return &MemoryStatus{}, err
+	}
+	return &memoryStatusEx.MemoryStatus, nil
+}
+
+// getMemoryStatusEx implements GetMemoryStatusEx. The page file values of
+// MEMORYSTATUSEX are the commit limit and the available commit, and are
+// reported both as swap and commit values.
+func (s Proci) getMemoryStatusEx() (*MemoryStatusEx, error) {
 	mStatEx := new(winMemoryStatusEx)
 	mStatEx.DwLength = winDWord(unsafe.Sizeof(*mStatEx))
 
 	ret, _, err := globalMemoryStatusEx.Call(uintptr(unsafe.Pointer(mStatEx)))
 	if ret == 0 {
-		return &MemoryStatus{}, fmt.Errorf("unable to get physical memory info. Reason: %s", err)
+		return nil, fmt.Errorf("unable to get physical memory info. Reason: %s", err)
 	}
 
-	return &MemoryStatus{
-		MemoryLoad: uint32(mStatEx.DwMemoryLoad),
-		TotalPhys:  uint64(mStatEx.UllTotalPhys),
-		AvailPhys:  uint64(mStatEx.UllAvailPhys)}, nil
+	return &MemoryStatusEx{
+		MemoryStatus: MemoryStatus{
+			MemoryLoad: uint32(mStatEx.DwMemoryLoad),
+			TotalPhys:  uint64(mStatEx.UllTotalPhys),
+			AvailPhys:  uint64(mStatEx.UllAvailPhys)},
+		SwapTotal:   uint64(mStatEx.UllTotalPageFile),
+		SwapFree:    uint64(mStatEx.UllAvailPageFile),
+		Committed:   uint64(mStatEx.UllTotalPageFile - mStatEx.UllAvailPageFile),
+		CommitLimit: uint64(mStatEx.UllTotalPageFile)}, nil
 }
 
 //////////////////////////////////////////////////////////////////////////////
 // List processes
 
-// Maximum number of processes
-const maxProcesses = 2000
-
-// getProcessPids implements GetProcessPids
-func getProcessPids() []uint32 {
-	var bytesReturned uint32
-	pids := make([]uint32, maxProcesses, maxProcesses)
-	ret, _, err := enumProcesses.Call(
-		uintptr(unsafe.Pointer(&pids[0])),
-		uintptr(len(pids)*4),
-		uintptr(unsafe.Pointer(&bytesReturned)))
-	if ret == 0 {
-		panic(fmt.Sprintf("Unable to list processes. Reason: %s", err))
-	}
-	nbrOfPids := int(bytesReturned / 4)
-	if nbrOfPids >= len(pids) {
-		panic(fmt.Sprintf("More running processes than configured max capacity of %d\n", maxProcesses))
+// Initial number of PIDS to allocate room for. Doubled until all fits.
+const initialPidsCapacity = 1024
+
+// listProcessPids implements ListProcessPids
+func (s Proci) listProcessPids() ([]uint32, error) {
+	for capacity := initialPidsCapacity; ; capacity *= 2 {
+		var bytesReturned uint32
+		pids := make([]uint32, capacity)
+		ret, _, err := enumProcesses.Call(
+			uintptr(unsafe.Pointer(&pids[0])),
+			uintptr(len(pids)*4),
+			uintptr(unsafe.Pointer(&bytesReturned)))
+		if ret == 0 {
+			return nil, fmt.Errorf("unable to list processes. Reason: %s", err)
+		}
+		nbrOfPids := int(bytesReturned / 4)
+		if nbrOfPids < len(pids) {
+			// If the buffer was filled there might be more processes
+			return pids[:nbrOfPids], nil // Shrink to fit number of PIDS
+		}
 	}
-	pids = pids[:nbrOfPids] // Shrik to fit number of PIDS
-	return pids
 }
 
 //////////////////////////////////////////////////////////////////////////////
@@ -193,34 +248,69 @@ type winProcessMemoryCountersEx struct {
 }
 
 // getProcessMemoryUsage implements GetProcessMemoryUsage.
-func getProcessMemoryUsage(pid uint32) (uint64, error) {
+func (s Proci) getProcessMemoryUsage(pid uint32) (uint64, error) {
 	handle, err := openProc(pid, opBasic)
 	if err != nil {
 		return 0, err
 	}
 	defer closeProc(handle)
 
+	return processMemoryUsage(handle)
+}
+
+// getProcessMemoryInfo implements GetProcessMemoryInfo.
+func (s Proci) getProcessMemoryInfo(pid uint32) (*MemoryInfo, error) {
+	handle, err := openProc(pid, opBasic)
+	if err != nil {
+		return nil, err
+	}
+	defer closeProc(handle)
+
+	return processMemoryInfo(handle)
+}
+
+// Gets the memory usage of an opened process.
+func processMemoryUsage(handle uintptr) (uint64, error) {
+	memoryInfo, err := processMemoryInfo(handle)
+	if err != nil {
+		return 0, err
+	}
+	return memoryInfo.Private, nil
+}
+
+// Gets the memory information of an opened process.
+func processMemoryInfo(handle uintptr) (*MemoryInfo, error) {
 	procMemCntrEx := new(winProcessMemoryCountersEx)
-	cb := uintptr(unsafe.Sizeof(*procMemCntrEx))
+	procMemCntrEx.cb = winDWord(unsafe.Sizeof(*procMemCntrEx))
+	cb := uintptr(procMemCntrEx.cb)
 
-	ret, _, err2 := getProcessMemoryInfo.Call(handle, uintptr(unsafe.Pointer(procMemCntrEx)), cb)
+	ret, _, err := getProcessMemoryInfo.Call(handle, uintptr(unsafe.Pointer(procMemCntrEx)), cb)
 	if ret == 0 {
-		return 0, fmt.Errorf("unable to get physical memory info. Reason: %s", err2)
+		return nil, fmt.Errorf("unable to get physical memory info. Reason: %s", err)
 	}
-	return uint64(procMemCntrEx.PrivateUsage), nil
+	return &MemoryInfo{
+		RSS:     uint64(procMemCntrEx.WorkingSetSize),
+		VMS:     uint64(procMemCntrEx.PagefileUsage),
+		PeakRSS: uint64(procMemCntrEx.PeakWorkingSetSize),
+		Private: uint64(procMemCntrEx.PrivateUsage)}, nil
 }
 
 //////////////////////////////////////////////////////////////////////////////
 // Get process path (which also includes the process name).
 
 // getProcessPath implements GetProcessPath.
-func getProcessPath(pid uint32) (string, error) {
+func (s Proci) getProcessPath(pid uint32) (string, error) {
 	handle, err := openProc(pid, opBasic)
 	if err != nil {
 		return "", err
 	}
 	defer closeProc(handle)
 
+	return processPath(handle), nil
+}
+
+// Gets the path of an opened process.
+func processPath(handle uintptr) string {
 	var lpImageFileName = make([]uint16, syscall.MAX_PATH+1)
 	var nSize = uintptr(len(lpImageFileName))
 
@@ -229,22 +319,66 @@ func getProcessPath(pid uint32) (string, error) {
 		// Here we don't know if there was no path (for example the Kernel process
 		// with PID 4 don't have it) or if there was an error. We assume that the
 		// path is empty.
-		return "", nil
+		return ""
 	}
-	return syscall.UTF16ToString(lpImageFileName), nil
+	return syscall.UTF16ToString(lpImageFileName)
 }
 
 //////////////////////////////////////////////////////////////////////////////
-// Get process command line arguments
+// Get process user
 
+// getProcessUser implements GetProcessUser.
+func (s Proci) getProcessUser(pid uint32) (*ProcessUser, error) {
+	return nil, fmt.Errorf("%w: process user is not available on Windows", ErrNotSupported)
+}
+
+//////////////////////////////////////////////////////////////////////////////
+// Get parent process
+
+// PROCESS_BASIC_INFORMATION
 type winProcessBasicInformation struct {
-	Reserved1       winPVoid
-	PebBaseAddress  winPointer
-	Reserved2       [2]winPVoid
-	UniqueProcessID winPointer
-	Reserved3       winPVoid
+	Reserved1                    winPVoid
+	PebBaseAddress               winPointer
+	Reserved2                    [2]winPVoid
+	UniqueProcessID              winPointer
+	InheritedFromUniqueProcessID winPointer
+}
+
+// getProcessParentPid implements GetProcessParentPid.
+func (s Proci) getProcessParentPid(pid uint32) (uint32, error) {
+	handle, err := openProc(pid, opBasic)
+	if err != nil {
+		return 0, err
+	}
+	defer closeProc(handle)
+
+	procBasicInf, err2 := queryBasicInformation(handle)
+	if err2 != nil {
+		return 0, err2
+	}
+	return uint32(procBasicInf.InheritedFromUniqueProcessID), nil
+}
+
+// Queries the PROCESS_BASIC_INFORMATION of an opened process.
+func queryBasicInformation(handle uintptr) (*winProcessBasicInformation, error) {
+	procBasicInf := new(winProcessBasicInformation)
+	procBasicInfSize := uintptr(unsafe.Sizeof(*procBasicInf))
+	var returnLength uint32
+	ret, _, err := ntQueryInformationProcess.Call(
+		handle,
+		0, // ProcessBasicInformation
+		uintptr(unsafe.Pointer(procBasicInf)),
+		procBasicInfSize,
+		uintptr(unsafe.Pointer(&returnLength)))
+	if ret != 0 {
+		return nil, fmt.Errorf("unable to query process information. Reason: %s", err)
+	}
+	return procBasicInf, nil
 }
 
+//////////////////////////////////////////////////////////////////////////////
+// Get process command line arguments
+
 type winPEB struct {
 	Reserved1              [2]winByte
 	BeingDebugged          winByte
@@ -261,88 +395,615 @@ type winPEB struct {
 }
 
 type winRTLUserProcessParameters struct {
-	Reserved1     [16]winByte
-	Reserved2     [10]winPVoid
-	ImagePathName winUnicodeString
-	CommandLine   winUnicodeString
+	Reserved1        [16]winByte
+	Reserved2        [5]winPVoid
+	CurrentDirectory winUnicodeString // CURDIR.DosPath
+	Reserved3        winPVoid         // CURDIR.Handle
+	DllPath          winUnicodeString
+	ImagePathName    winUnicodeString
+	CommandLine      winUnicodeString
+	Environment      winPointer
+	Reserved4        [872]winByte // Up to offset 0x3f0
+	EnvironmentSize  winSizeT     // Available since Windows Vista
 }
 
 // getProcessCommandLine implements GetProcessCommandLine.
-func getProcessCommandLine(pid uint32) (string, error) {
+func (s Proci) getProcessCommandLine(pid uint32) (string, error) {
 	handle, err := openProc(pid, opReadVM)
 	if err != nil {
 		return "", err
 	}
 	defer closeProc(handle)
 
-	/////////////
-	procBasicInf := new(winProcessBasicInformation)
-	procBasicInfSize := uintptr(unsafe.Sizeof(*procBasicInf))
-	var returnLength uint32
-	ret, _, err2 := ntQueryInformationProcess.Call(
+	return processCommandLine(handle)
+}
+
+// getProcessArgs implements GetProcessArgs.
+func (s Proci) getProcessArgs(pid uint32) ([]string, error) {
+	commandLine, err := s.getProcessCommandLine(pid)
+	if err != nil {
+		return nil, err
+	}
+	return splitCommandLine(commandLine), nil
+}
+
+// Gets the command line of a process opened with opReadVM.
+func processCommandLine(handle uintptr) (string, error) {
+	userProcessParameters, err := readUserProcessParameters(handle)
+	if err != nil {
+		return "", err
+	}
+
+	cmdUnicode := userProcessParameters.CommandLine
+	commandLine, err2 := readUnicodeString(handle, cmdUnicode)
+	if err2 != nil {
+		return "", fmt.Errorf("unable to read command line. Reason: %w", err2)
+	}
+	return commandLine, nil
+}
+
+// getProcessEnviron implements GetProcessEnviron.
+func (s Proci) getProcessEnviron(pid uint32) (map[string]string, error) {
+	handle, err := openProc(pid, opReadVM)
+	if err != nil {
+		return nil, err
+	}
+	defer closeProc(handle)
+
+	userProcessParameters, err2 := readUserProcessParameters(handle)
+	if err2 != nil {
+		return nil, err2
+	}
+	size := userProcessParameters.EnvironmentSize
+	if size < 2 {
+		return map[string]string{}, nil
+	}
+
+	// The environment block is "KEY=value" strings separated by NUL
+	// characters and ending with an empty string
+	buffer := make([]uint16, size/2)
+	err3 := readProcMemory(
 		handle,
-		0,
-		uintptr(unsafe.Pointer(procBasicInf)),
-		procBasicInfSize,
-		uintptr(unsafe.Pointer(&returnLength)))
-	if ret != 0 {
-		return "", fmt.Errorf("unable to query process information. Reason: %s", err2)
+		uintptr(userProcessParameters.Environment),
+		uintptr(unsafe.Pointer(&buffer[0])),
+		uintptr(len(buffer)*2))
+	if err3 != nil {
+		return nil, fmt.Errorf("unable to read environment. Reason: %w", err3)
+	}
+	var entries []string
+	for start := 0; start < len(buffer) && buffer[start] != 0; {
+		end := start
+		for end < len(buffer) && buffer[end] != 0 {
+			end++
+		}
+		entries = append(entries, syscall.UTF16ToString(buffer[start:end]))
+		start = end + 1
+	}
+	return parseEnviron(entries), nil
+}
+
+// getProcessCwd implements GetProcessCwd.
+func (s Proci) getProcessCwd(pid uint32) (string, error) {
+	handle, err := openProc(pid, opReadVM)
+	if err != nil {
+		return "", err
+	}
+	defer closeProc(handle)
+
+	userProcessParameters, err2 := readUserProcessParameters(handle)
+	if err2 != nil {
+		return "", err2
+	}
+	cwd, err3 := readUnicodeString(handle, userProcessParameters.CurrentDirectory)
+	if err3 != nil {
+		return "", fmt.Errorf("unable to read current directory. Reason: %w", err3)
+	}
+	// The directory always ends with a backslash, keep it only for roots
+	// such as C:\
+	if len(cwd) > 3 {
+		cwd = strings.TrimSuffix(cwd, `\`)
+	}
+	return cwd, nil
+}
+
+// Reads the RTL_USER_PROCESS_PARAMETERS structure of a process opened with
+// opReadVM. The structure is found via the PEB of the process.
+func readUserProcessParameters(handle uintptr) (*winRTLUserProcessParameters, error) {
+	/////////////
+	procBasicInf, err := queryBasicInformation(handle)
+	if err != nil {
+		return nil, err
 	}
 
 	/////////////
 	peb := new(winPEB)
 
-	err3 := readProcMemory(
+	err2 := readProcMemory(
 		handle,
 		uintptr(procBasicInf.PebBaseAddress),
 		uintptr(unsafe.Pointer(peb)),
 		uintptr(unsafe.Sizeof(*peb)))
-	if err3 != nil {
-		return "", fmt.Errorf("unable to read PEB structure. Reason: %s", err3)
+	if err2 != nil {
+		return nil, fmt.Errorf("unable to read PEB structure. Reason: %w", err2)
 	}
 
 	/////////////
 	userProcessParameters := new(winRTLUserProcessParameters)
 
-	err4 := readProcMemory(
+	err3 := readProcMemory(
 		handle,
 		uintptr(peb.ProcessParameters),
 		uintptr(unsafe.Pointer(userProcessParameters)),
 		uintptr(unsafe.Sizeof(*userProcessParameters)))
-	if err4 != nil {
-		return "", fmt.Errorf("unable to read PEB process memory. Reason: %s", err4)
+	if err3 != nil {
+		return nil, fmt.Errorf("unable to read PEB process memory. Reason: %w", err3)
 	}
+	return userProcessParameters, nil
+}
 
-	/////////////
-	cmdUnicode := userProcessParameters.CommandLine
-	commandLineBuffer := make([]uint16, cmdUnicode.Length)
+// Reads the string that an UNICODE_STRING structure in another process
+// refers to.
+func readUnicodeString(handle uintptr, unicodeString winUnicodeString) (string, error) {
+	if unicodeString.Length == 0 {
+		return "", nil
+	}
+	buffer := make([]uint16, unicodeString.Length/2)
 
-	err5 := readProcMemory(
+	err := readProcMemory(
 		handle,
-		uintptr(cmdUnicode.Buffer),
-		uintptr(unsafe.Pointer(&commandLineBuffer[0])),
-		uintptr(cmdUnicode.Length))
-	if err5 != nil {
-		return "", fmt.Errorf("unable to read command line. Reason: %s", err5)
+		uintptr(unicodeString.Buffer),
+		uintptr(unsafe.Pointer(&buffer[0])),
+		uintptr(unicodeString.Length))
+	if err != nil {
+		return "", err
 	}
+	return syscall.UTF16ToString(buffer), nil
+}
+
+//////////////////////////////////////////////////////////////////////////////
+// Get process CPU times
 
-	return syscall.UTF16ToString(commandLineBuffer), nil
+// getProcessCPUTimes implements GetProcessCPUTimes.
+func (s Proci) getProcessCPUTimes(pid uint32) (*CPUTimes, error) {
+	handle, err := openProc(pid, opBasic)
+	if err != nil {
+		return nil, err
+	}
+	defer closeProc(handle)
+
+	times, err := processTimes(handle)
+	if err != nil {
+		return nil, err
+	}
+	return &CPUTimes{
+		User:   times.UserTime.duration(),
+		System: times.KernelTime.duration()}, nil
+}
+
+// The times returned by GetProcessTimes
+type winProcessTimes struct {
+	CreationTime winFileTime
+	ExitTime     winFileTime
+	KernelTime   winFileTime
+	UserTime     winFileTime
+}
+
+// Gets the times of a process
+func processTimes(handle uintptr) (*winProcessTimes, error) {
+	var times winProcessTimes
+	ret, _, err := getProcessTimes.Call(
+		handle,
+		uintptr(unsafe.Pointer(&times.CreationTime)),
+		uintptr(unsafe.Pointer(&times.ExitTime)),
+		uintptr(unsafe.Pointer(&times.KernelTime)),
+		uintptr(unsafe.Pointer(&times.UserTime)))
+	if ret == 0 {
+		return nil, fmt.Errorf("unable to get process times. Reason: %s", err)
+	}
+	return &times, nil
+}
+
+// Converts a FILETIME holding an amount of time to a duration. The unit of
+// FILETIME is 100 nanoseconds.
+func (t winFileTime) duration() time.Duration {
+	return time.Duration(uint64(t.HighDateTime)<<32|uint64(t.LowDateTime)) * 100
+}
+
+// Converts a FILETIME holding a point in time to a time. The FILETIME is the
+// number of 100 nanoseconds since January 1, 1601 (UTC).
+func (t winFileTime) time() time.Time {
+	filetime := syscall.Filetime{
+		LowDateTime:  uint32(t.LowDateTime),
+		HighDateTime: uint32(t.HighDateTime)}
+	return time.Unix(0, filetime.Nanoseconds())
+}
+
+//////////////////////////////////////////////////////////////////////////////
+// Get process I/O counters
+
+// IO_COUNTERS
+type winIOCounters struct {
+	ReadOperationCount  winDWordLong
+	WriteOperationCount winDWordLong
+	OtherOperationCount winDWordLong
+	ReadTransferCount   winDWordLong
+	WriteTransferCount  winDWordLong
+	OtherTransferCount  winDWordLong
+}
+
+// getProcessIOCounters implements GetProcessIOCounters.
+func (s Proci) getProcessIOCounters(pid uint32) (*IOCounters, error) {
+	handle, err := openProc(pid, opBasic)
+	if err != nil {
+		return nil, err
+	}
+	defer closeProc(handle)
+
+	var counters winIOCounters
+	ret, _, err := getProcessIoCounters.Call(handle, uintptr(unsafe.Pointer(&counters)))
+	if ret == 0 {
+		return nil, fmt.Errorf("unable to get I/O counters of process %d. Reason: %s", pid, err)
+	}
+	return &IOCounters{
+		ReadCount:  uint64(counters.ReadOperationCount),
+		WriteCount: uint64(counters.WriteOperationCount),
+		ReadChars:  uint64(counters.ReadTransferCount),
+		WriteChars: uint64(counters.WriteTransferCount),
+		ReadBytes:  uint64(counters.ReadTransferCount),
+		WriteBytes: uint64(counters.WriteTransferCount)}, nil
+}
+
+//////////////////////////////////////////////////////////////////////////////
+// Get process start time
+
+// getProcessStartTime implements GetProcessStartTime.
+func (s Proci) getProcessStartTime(pid uint32) (time.Time, error) {
+	handle, err := openProc(pid, opBasic)
+	if err != nil {
+		return time.Time{}, err
+	}
+	defer closeProc(handle)
+
+	times, err := processTimes(handle)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return times.CreationTime.time(), nil
+}
+
+// getProcessKey implements GetProcessKey.
+func (s Proci) getProcessKey(pid uint32) (ProcessKey, error) {
+	startTime, err := s.getProcessStartTime(pid)
+	if err != nil {
+		return ProcessKey{}, err
+	}
+	return newProcessKey(pid, startTime), nil
+}
+
+//////////////////////////////////////////////////////////////////////////////
+// Get process state
+
+// getProcessState implements GetProcessState.
+func (s Proci) getProcessState(pid uint32) (State, error) {
+	return StateUnknown, fmt.Errorf("%w: process state is not available on Windows", ErrNotSupported)
+}
+
+//////////////////////////////////////////////////////////////////////////////
+// Get process threads
+
+const th32csSnapProcess = 0x00000002             // TH32CS_SNAPPROCESS
+const th32csSnapThread = 0x00000004              // TH32CS_SNAPTHREAD
+const threadQueryLimitedInformation = 0x00000800 // THREAD_QUERY_LIMITED_INFORMATION
+
+// Returned by CreateToolhelp32Snapshot on failure
+const winInvalidHandleValue = ^uintptr(0) // INVALID_HANDLE_VALUE
+
+// THREADENTRY32
+type winThreadEntry32 struct {
+	Size           winDWord
+	Usage          winDWord
+	ThreadID       winDWord
+	OwnerProcessID winDWord
+	BasePri        winLong
+	DeltaPri       winLong
+	Flags          winDWord
+}
+
+// PROCESSENTRY32W
+type winProcessEntry32 struct {
+	Size            winDWord
+	Usage           winDWord
+	ProcessID       winDWord
+	DefaultHeapID   winPointer
+	ModuleID        winDWord
+	Threads         winDWord
+	ParentProcessID winDWord
+	PriClassBase    winLong
+	Flags           winDWord
+	ExeFile         [260]uint16 // MAX_PATH wide characters
+}
+
+// getProcessThreads implements GetProcessThreads.
+func (s Proci) getProcessThreads(pid uint32) ([]Thread, error) {
+	tids, err := listThreadIds(pid)
+	if err != nil {
+		return nil, err
+	}
+	threads := make([]Thread, 0, len(tids))
+	for _, tid := range tids {
+		thread := Thread{Tid: tid}
+		times, err := threadCPUTimes(tid)
+		if errors.Is(err, winErrorInvalidParameter) {
+			// The thread has exited
+			continue
+		}
+		if err == nil {
+			thread.CPUTimes = *times
+		}
+		threads = append(threads, thread)
+	}
+	return threads, nil
+}
+
+// Lists the IDs of the threads of a process, sorted by thread ID
+func listThreadIds(pid uint32) ([]uint32, error) {
+	snapshot, _, err := createToolhelp32Snapshot.Call(th32csSnapThread, 0)
+	if snapshot == winInvalidHandleValue {
+		return nil, fmt.Errorf("unable to list threads. Reason: %s", err)
+	}
+	defer closeHandle.Call(snapshot)
+
+	var tids []uint32
+	entry := winThreadEntry32{Size: winDWord(unsafe.Sizeof(winThreadEntry32{}))}
+	ret, _, err := thread32First.Call(snapshot, uintptr(unsafe.Pointer(&entry)))
+	for ret != 0 {
+		if uint32(entry.OwnerProcessID) == pid {
+			tids = append(tids, uint32(entry.ThreadID))
+		}
+		ret, _, err = thread32Next.Call(snapshot, uintptr(unsafe.Pointer(&entry)))
+	}
+	if err != syscall.ERROR_NO_MORE_FILES {
+		return nil, fmt.Errorf("unable to list threads. Reason: %s", err)
+	}
+	if len(tids) == 0 {
+		return nil, fmt.Errorf("%w: no threads found for process %d", ErrProcessNotFound, pid)
+	}
+	sort.Slice(tids, func(i, j int) bool { return tids[i] < tids[j] })
+	return tids, nil
+}
+
+// Counts the threads of all processes, using a single snapshot of the
+// processes in the system instead of walking all threads.
+func countThreads() (map[uint32]int, error) {
+	snapshot, _, err := createToolhelp32Snapshot.Call(th32csSnapProcess, 0)
+	if snapshot == winInvalidHandleValue {
+		return nil, fmt.Errorf("unable to list processes. Reason: %s", err)
+	}
+	defer closeHandle.Call(snapshot)
+
+	counts := make(map[uint32]int)
+	entry := winProcessEntry32{Size: winDWord(unsafe.Sizeof(winProcessEntry32{}))}
+	ret, _, err := process32First.Call(snapshot, uintptr(unsafe.Pointer(&entry)))
+	for ret != 0 {
+		counts[uint32(entry.ProcessID)] = int(entry.Threads)
+		ret, _, err = process32Next.Call(snapshot, uintptr(unsafe.Pointer(&entry)))
+	}
+	if err != syscall.ERROR_NO_MORE_FILES {
+		return nil, fmt.Errorf("unable to list processes. Reason: %s", err)
+	}
+	return counts, nil
+}
+
+// Gets the CPU times of a thread
+func threadCPUTimes(tid uint32) (*CPUTimes, error) {
+	handle, _, err := openThread.Call(threadQueryLimitedInformation, 0, uintptr(tid))
+	if handle == 0 {
+		return nil, fmt.Errorf("unable to open thread %d. Reason: %w", tid, err)
+	}
+	defer closeHandle.Call(handle)
+
+	var times winProcessTimes
+	ret, _, err := getThreadTimes.Call(
+		handle,
+		uintptr(unsafe.Pointer(&times.CreationTime)),
+		uintptr(unsafe.Pointer(&times.ExitTime)),
+		uintptr(unsafe.Pointer(&times.KernelTime)),
+		uintptr(unsafe.Pointer(&times.UserTime)))
+	if ret == 0 {
+		return nil, fmt.Errorf("unable to get thread times. Reason: %s", err)
+	}
+	return &CPUTimes{
+		User:   times.UserTime.duration(),
+		System: times.KernelTime.duration()}, nil
+}
+
+//////////////////////////////////////////////////////////////////////////////
+// Get process open files
+
+// getProcessOpenFiles implements GetProcessOpenFiles.
+func (s Proci) getProcessOpenFiles(pid uint32) ([]OpenFile, error) {
+	return nil, fmt.Errorf("%w: open files are not available on Windows", ErrNotSupported)
+}
+
+// getProcessFdCount implements GetProcessFdCount.
+func (s Proci) getProcessFdCount(pid uint32) (int, error) {
+	handle, err := openProc(pid, opBasic)
+	if err != nil {
+		return 0, err
+	}
+	defer closeProc(handle)
+
+	var count winDWord
+	ret, _, err := getProcessHandleCount.Call(handle, uintptr(unsafe.Pointer(&count)))
+	if ret == 0 {
+		return 0, fmt.Errorf("unable to get handle count of process %d. Reason: %s", pid, err)
+	}
+	return int(count), nil
+}
+
+//////////////////////////////////////////////////////////////////////////////
+// Get process limits
+
+// getProcessLimits implements GetProcessLimits.
+func (s Proci) getProcessLimits(pid uint32) (map[string]Limit, error) {
+	return nil, fmt.Errorf("%w: process limits are not available on Windows", ErrNotSupported)
+}
+
+//////////////////////////////////////////////////////////////////////////////
+// Get process cgroups
+
+// getProcessCgroups implements GetProcessCgroups.
+func (s Proci) getProcessCgroups(pid uint32) ([]Cgroup, error) {
+	return nil, fmt.Errorf("%w: cgroups are not available on Windows", ErrNotSupported)
+}
+
+//////////////////////////////////////////////////////////////////////////////
+// Get cgroup statistics
+
+// cgroupStats implements CgroupStats.
+func (s Proci) cgroupStats(path string) (*CgroupResourceStats, error) {
+	return nil, fmt.Errorf("%w: cgroups are not available on Windows", ErrNotSupported)
+}
+
+//////////////////////////////////////////////////////////////////////////////
+// Get process namespaces
+
+// getProcessNamespaces implements GetProcessNamespaces.
+func (s Proci) getProcessNamespaces(pid uint32) (*Namespaces, error) {
+	return nil, fmt.Errorf("%w: namespaces are not available on Windows", ErrNotSupported)
+}
+
+//////////////////////////////////////////////////////////////////////////////
+// Get process memory maps
+
+// getProcessMemoryMaps implements GetProcessMemoryMaps.
+func (s Proci) getProcessMemoryMaps(pid uint32) ([]MemoryMap, error) {
+	return nil, fmt.Errorf("%w: memory maps are not available on Windows", ErrNotSupported)
+}
+
+//////////////////////////////////////////////////////////////////////////////
+// Get network connections
+
+// getProcessConnections implements GetProcessConnections.
+func (s Proci) getProcessConnections(pid uint32) ([]Connection, error) {
+	return nil, fmt.Errorf("%w: connections are not available on Windows", ErrNotSupported)
+}
+
+// connections implements Connections.
+func (s Proci) connections() ([]Connection, error) {
+	return nil, fmt.Errorf("%w: connections are not available on Windows", ErrNotSupported)
+}
+
+//////////////////////////////////////////////////////////////////////////////
+// Get process information in one call
+
+// getProcess implements GetProcess.
+func (s Proci) getProcess(pid uint32) (*Process, error) {
+	handle, err := openProc(pid, opReadVM)
+	readVMErr := err
+	if errors.Is(err, ErrAccessDenied) {
+		// Retry with less access rights. The command line cannot be read then.
+		handle, err = openProc(pid, opBasic)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer closeProc(handle)
+
+	process := &Process{Pid: pid}
+	if procBasicInf, err := queryBasicInformation(handle); err == nil {
+		process.ParentPid = uint32(procBasicInf.InheritedFromUniqueProcessID)
+	} else {
+		process.ParentPidErr = err
+	}
+	process.Path = processPath(handle)
+	if process.Path != "" {
+		process.Name = filepath.Base(process.Path)
+	}
+	if readVMErr == nil {
+		process.CommandLine, process.CommandLineErr = processCommandLine(handle)
+	} else {
+		process.CommandLineErr = readVMErr
+	}
+	process.MemoryUsage, process.MemoryUsageErr = processMemoryUsage(handle)
+	if times, err := processTimes(handle); err == nil {
+		process.StartTime = times.CreationTime.time()
+	} else {
+		process.StartTimeErr = err
+	}
+	process.User, process.UserErr = s.getProcessUser(pid)
+	process.State, process.StateErr = s.getProcessState(pid)
+	process.Cgroups, process.CgroupsErr = s.getProcessCgroups(pid)
+	process.Namespaces, process.NamespacesErr = s.getProcessNamespaces(pid)
+	process.ThreadCount, process.ThreadCountErr = s.threadCount(pid)
+	return process, nil
 }
 
 //////////////////////////////////////////////////////////////////////////////
 // Internal functions
 
+// Information that is read once per snapshot, see Proci.
+type snapshotCache struct {
+	threadCounts    map[uint32]int
+	threadCountsErr error
+}
+
+// Reads the information that is the same for all processes.
+func (s Proci) newSnapshotCache() *snapshotCache {
+	cache := &snapshotCache{}
+	cache.threadCounts, cache.threadCountsErr = countThreads()
+	return cache
+}
+
+// Returns the number of threads of the process. The count is taken from the
+// snapshot cache if set, otherwise all processes are counted.
+func (s Proci) threadCount(pid uint32) (int, error) {
+	cache := s.cache
+	if cache == nil {
+		cache = s.newSnapshotCache()
+	}
+	if cache.threadCountsErr != nil {
+		return 0, cache.threadCountsErr
+	}
+	count, found := cache.threadCounts[pid]
+	if !found {
+		// Started after the snapshot cache was read
+		return 0, fmt.Errorf("thread count of process %d not found", pid)
+	}
+	return count, nil
+}
+
 const opReadVM = 0x00000410 // PROCESS_QUERY_INFORMATION | PROCESS_VM_READ
 const opBasic = 0x00001000  // PROCESS_QUERY_LIMITED_INFORMATION
 
-// Opens a process and returns the process handle
+// Returned by OpenProcess if the PID doesn't exist
+const winErrorInvalidParameter = syscall.Errno(87) // ERROR_INVALID_PARAMETER
+
+// Opens a process and returns the process handle. The debug priviledge is
+// enabled first, if possible, so that privileged processes can be opened
+// regardless of which function is called first. Without the priviledge the
+// process is still opened, which works for processes of the same user.
 // Note! Close the process with closeProcess
 func openProc(pid uint32, accessLevel uint32) (uintptr, error) {
+	if pid == 0 {
+		// The System Idle Process is listed but can never be opened
+		return 0, fmt.Errorf("%w: unable to open process %d. Reason: the System Idle Process cannot be opened", ErrAccessDenied, pid)
+	}
+	priviledgeErr := enableDebugPriviledge()
 	handle, _, err := openProcess.Call(
 		uintptr(accessLevel),
 		0,
 		uintptr(pid))
 	if handle == 0 {
+		switch err {
+		case winErrorInvalidParameter:
+			return 0, fmt.Errorf("%w: unable to open process %d. Reason: %s", ErrProcessNotFound, pid, err)
+		case syscall.ERROR_ACCESS_DENIED:
+			if priviledgeErr != nil {
+				return 0, fmt.Errorf("%w: unable to open process %d. Reason: %s (debug priviledge not enabled: %s)", ErrAccessDenied, pid, err, priviledgeErr)
+			}
+			return 0, fmt.Errorf("%w: unable to open process %d. Reason: %s", ErrAccessDenied, pid, err)
+		}
 		return 0, fmt.Errorf("unable to open process %d. Reason: %s", pid, err)
 	}
 	return handle, nil
@@ -367,6 +1028,9 @@ func readProcMemory(handle uintptr, address uintptr, bufferPointer uintptr, buff
 		bufferSize,
 		uintptr(unsafe.Pointer(&numberOfBytesRead)))
 	if ret == 0 {
+		if err == syscall.ERROR_ACCESS_DENIED {
+			return fmt.Errorf("%w: unable to read memory. Reason: %s", ErrAccessDenied, err)
+		}
 		return fmt.Errorf("unable to read memory. Reason: %s", err)
 	}
 	if bufferSize != numberOfBytesRead {
@@ -2,28 +2,83 @@ package proci
 
 import (
 	"fmt"
+	"path/filepath"
+	"sort"
+	"time"
 )
 
 type ProcessMock struct{
 	Pid                uint32
+	ParentPid          uint32
 	Path               string
 	CommandLine        string
+	Args               []string
+	Environ            map[string]string
+	Cwd                string
+	User               *ProcessUser
 	MemoryUsage        uint64
+	MemoryInfo         *MemoryInfo // If nil, GetProcessMemoryInfo returns MemoryUsage as RSS
+	MemoryMaps         []MemoryMap
+	CPUTimes           []CPUTimes // One per GetProcessCPUTimes call, the last is repeated
+	IOCounters         []IOCounters // One per GetProcessIOCounters call, the last is repeated
+	StartTime          time.Time
+	State              State
+	Threads            []Thread
+	OpenFiles          []OpenFile // GetProcessFdCount returns the number of open files
+	Connections        []Connection // Pid is set to the PID of the process when returned
+	Limits             map[string]Limit
+	Cgroups            []Cgroup
+	Namespaces         *Namespaces
 	
+	DoFailParentPid    bool   // If true, fail GetProcessParentPid
 	DoFailPath         bool   // If true, fail GetProcessPath
 	DoFailCommandLine  bool   // If true, fail GetProcessCommandLine
+	DoFailArgs         bool   // If true, fail GetProcessArgs
+	DoFailEnviron      bool   // If true, fail GetProcessEnviron with ErrAccessDenied
+	DoFailCwd          bool   // If true, fail GetProcessCwd
+	DoFailUser         bool   // If true, fail GetProcessUser
 	DoFailMemoryUsage  bool   // If true, fail GetProcessMemoryUsage
+	DoFailMemoryInfo   bool   // If true, fail GetProcessMemoryInfo
+	DoFailMemoryMaps   bool   // If true, fail GetProcessMemoryMaps
+	DoFailCPUTimes     bool   // If true, fail GetProcessCPUTimes
+	DoFailIOCounters   bool   // If true, fail GetProcessIOCounters
+	DoFailStartTime    bool   // If true, fail GetProcessStartTime
+	DoFailState        bool   // If true, fail GetProcessState
+	DoFailThreads      bool   // If true, fail GetProcessThreads
+	DoFailOpenFiles    bool   // If true, fail GetProcessOpenFiles and GetProcessFdCount
+	DoFailConnections  bool   // If true, fail GetProcessConnections
+	DoFailLimits       bool   // If true, fail GetProcessLimits
+	DoFailCgroups      bool   // If true, fail GetProcessCgroups
+	DoFailNamespaces   bool   // If true, fail GetProcessNamespaces
+	DoExit             bool   // If true, listed but act as if it has exited
+
+	cpuTimesCalls      int    // Number of GetProcessCPUTimes calls
+	ioCountersCalls    int    // Number of GetProcessIOCounters calls
 }
 
 // ProciMock is a mock implementation for the proci Interface. It is intended
 // for mocking of proci during unit testing.
 type ProciMock struct{
-	MemStatus        *MemoryStatus
-	DoFailMemStatus  bool   // If true, fail GetMemoryStatus
+	MemStatus          *MemoryStatus
+	MemStatusEx        *MemoryStatusEx // If nil, GetMemoryStatusEx extends MemStatus
+	CgroupStatsByPath  map[string]*CgroupResourceStats // Used by CgroupStats
+	DoFailMemStatus    bool   // If true, fail GetMemoryStatus and GetMemoryStatusEx
+	DoFailPids         bool   // If true, fail ListProcessPids
+	DoFailConnections  bool   // If true, fail Connections
+	DoFailCgroupStats  bool   // If true, fail CgroupStats
 	
-	Processes        map[uint32]*ProcessMock
+	Processes          map[uint32]*ProcessMock
 }
 
+// Start time of the first process generated by GenerateMock. The following
+// processes are started one second apart.
+var mockStartTime = time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// Namespaces of the processes generated by GenerateMock
+var hostNamespaces = Namespaces{
+	Mnt: 4026531841, Pid: 4026531836, Net: 4026531840, UTS: 4026531838,
+	IPC: 4026531839, User: 4026531837, Cgroup: 4026531835, Time: 4026531834}
+
 // GenerateMock generate a mock with mock processes. It will start from
 // PID 0 up to numberOfProcesses - 1. 
 func GenerateMock(numberOfProcesses int) *ProciMock {
@@ -31,11 +86,25 @@ func GenerateMock(numberOfProcesses int) *ProciMock {
 	processes := make(map[uint32]*ProcessMock)
 	for i := 0; i < numberOfProcesses; i++ {
 		pid := uint32(i)
+		namespaces := hostNamespaces
 		process := ProcessMock{
 			Pid : pid,
 			Path : fmt.Sprintf("path_%d", i),
 			CommandLine : fmt.Sprintf("command_line_%d", i),
+			Args : []string{fmt.Sprintf("command_line_%d", i)},
+			Environ : map[string]string{"PID": fmt.Sprint(i)},
+			Cwd : fmt.Sprintf("cwd_%d", i),
+			User : &ProcessUser{Username: "user", GroupName: "user", EffectiveUsername: "user", EffectiveGroupName: "user"},
 			MemoryUsage : uint64(1024 + i * 1024),
+			StartTime : mockStartTime.Add(time.Duration(i) * time.Second),
+			State : StateSleeping,
+			Threads : []Thread{{Tid: pid, Name: fmt.Sprintf("thread_%d", i), State: StateSleeping}},
+			OpenFiles : []OpenFile{{Fd: 0, Path: "/dev/null", Type: FileTypeFile}},
+			Limits : map[string]Limit{
+				LimitOpenFiles: {Soft: 1024, Hard: 4096, Unit: "files"},
+				LimitAddressSpace: {Soft: LimitUnlimited, Hard: LimitUnlimited, Unit: "bytes"}},
+			Cgroups : []Cgroup{{HierarchyID: 0, Path: "/user.slice"}},
+			Namespaces : &namespaces,
 			DoFailPath : false,
 			DoFailCommandLine : false,
 			DoFailMemoryUsage : false}
@@ -43,10 +112,25 @@ func GenerateMock(numberOfProcesses int) *ProciMock {
 	}
 	return &ProciMock{
 		MemStatus : &memoryStatus,
+		CgroupStatsByPath : map[string]*CgroupResourceStats{
+			"/user.slice": {MemoryCurrent: uint64(numberOfProcesses) * 4096, MemoryMax: LimitUnlimited}},
 		DoFailMemStatus : false,
 		Processes : processes}
 }
 
+// process returns the mocked process or an error wrapping ErrProcessNotFound
+// if the process does not exist or has exited.
+func (s ProciMock) process(pid uint32) (*ProcessMock, error) {
+	process, hasPid := s.Processes[pid]
+	if !hasPid {
+		return nil, fmt.Errorf("%w: PID %d does not exist", ErrProcessNotFound, pid)
+	}
+	if process.DoExit {
+		return nil, fmt.Errorf("%w: PID %d has exited", ErrProcessNotFound, pid)
+	}
+	return process, nil
+}
+
 func (s ProciMock) GetMemoryStatus() (*MemoryStatus, error) {
 	if s.DoFailMemStatus {
 		return nil, fmt.Errorf("GetMemoryStatus Mock intentional failure")
@@ -54,18 +138,37 @@ func (s ProciMock) GetMemoryStatus() (*MemoryStatus, error) {
 	return s.MemStatus, nil
 }
 
+func (s ProciMock) GetMemoryStatusEx() (*MemoryStatusEx, error) {
+	if s.DoFailMemStatus {
+		return nil, fmt.Errorf("GetMemoryStatusEx Mock intentional failure")
+	}
+	if s.MemStatusEx == nil {
+		return &MemoryStatusEx{MemoryStatus: *s.MemStatus}, nil
+	}
+	return s.MemStatusEx, nil
+}
+
 func (s ProciMock) GetProcessPids() []uint32 {
-	pids := make([]uint32, 0, len(s.Processes))
-  for pid, _ := range s.Processes { 
-    pids = append(pids, pid)
-  }
+	pids, _ := s.ListProcessPids()
 	return pids
 }
 
+func (s ProciMock) ListProcessPids() ([]uint32, error) {
+	if s.DoFailPids {
+		return nil, fmt.Errorf("ListProcessPids Mock intentional failure")
+	}
+	pids := make([]uint32, 0, len(s.Processes))
+	for pid := range s.Processes {
+		pids = append(pids, pid)
+	}
+	sort.Slice(pids, func(i, j int) bool { return pids[i] < pids[j] })
+	return pids, nil
+}
+
 func (s ProciMock) GetProcessMemoryUsage(pid uint32) (uint64, error) {
-	process, hasPid := s.Processes[pid]
-	if !hasPid {
-		return 0, fmt.Errorf("PID %d does not exist", pid)
+	process, err := s.process(pid)
+	if err != nil {
+		return 0, err
 	}
 	if process.DoFailMemoryUsage {
 		return 0, fmt.Errorf("GetProcessMemoryUsage Mock intentional failure")
@@ -73,10 +176,35 @@ func (s ProciMock) GetProcessMemoryUsage(pid uint32) (uint64, error) {
 	return process.MemoryUsage, nil
 }
 
+func (s ProciMock) GetProcessMemoryInfo(pid uint32) (*MemoryInfo, error) {
+	process, err := s.process(pid)
+	if err != nil {
+		return nil, err
+	}
+	if process.DoFailMemoryInfo {
+		return nil, fmt.Errorf("GetProcessMemoryInfo Mock intentional failure")
+	}
+	if process.MemoryInfo == nil {
+		return &MemoryInfo{RSS: process.MemoryUsage}, nil
+	}
+	return process.MemoryInfo, nil
+}
+
+func (s ProciMock) GetProcessMemoryMaps(pid uint32) ([]MemoryMap, error) {
+	process, err := s.process(pid)
+	if err != nil {
+		return nil, err
+	}
+	if process.DoFailMemoryMaps {
+		return nil, fmt.Errorf("GetProcessMemoryMaps Mock intentional failure")
+	}
+	return process.MemoryMaps, nil
+}
+
 func (s ProciMock) GetProcessPath(pid uint32) (string, error) {
-	process, hasPid := s.Processes[pid]
-	if !hasPid {
-		return "", fmt.Errorf("PID %d does not exist", pid)
+	process, err := s.process(pid)
+	if err != nil {
+		return "", err
 	}
 	if process.DoFailPath {
 		return "", fmt.Errorf("GetProcessPath Mock intentional failure")
@@ -85,9 +213,9 @@ func (s ProciMock) GetProcessPath(pid uint32) (string, error) {
 }
 
 func (s ProciMock) GetProcessCommandLine(pid uint32) (string, error) {
-	process, hasPid := s.Processes[pid]
-	if !hasPid {
-		return "", fmt.Errorf("PID %d does not exist", pid)
+	process, err := s.process(pid)
+	if err != nil {
+		return "", err
 	}
 	if process.DoFailCommandLine {
 		return "", fmt.Errorf("GetProcessCommandLine Mock intentional failure")
@@ -95,3 +223,263 @@ func (s ProciMock) GetProcessCommandLine(pid uint32) (string, error) {
 	return process.CommandLine, nil
 }
 
+func (s ProciMock) GetProcessArgs(pid uint32) ([]string, error) {
+	process, err := s.process(pid)
+	if err != nil {
+		return nil, err
+	}
+	if process.DoFailArgs {
+		return nil, fmt.Errorf("GetProcessArgs Mock intentional failure")
+	}
+	return process.Args, nil
+}
+
+func (s ProciMock) GetProcessEnviron(pid uint32) (map[string]string, error) {
+	process, err := s.process(pid)
+	if err != nil {
+		return nil, err
+	}
+	if process.DoFailEnviron {
+		return nil, fmt.Errorf("%w: GetProcessEnviron Mock intentional failure", ErrAccessDenied)
+	}
+	return process.Environ, nil
+}
+
+func (s ProciMock) GetProcessCwd(pid uint32) (string, error) {
+	process, err := s.process(pid)
+	if err != nil {
+		return "", err
+	}
+	if process.DoFailCwd {
+		return "", fmt.Errorf("GetProcessCwd Mock intentional failure")
+	}
+	return process.Cwd, nil
+}
+
+func (s ProciMock) GetProcessUser(pid uint32) (*ProcessUser, error) {
+	process, err := s.process(pid)
+	if err != nil {
+		return nil, err
+	}
+	if process.DoFailUser {
+		return nil, fmt.Errorf("GetProcessUser Mock intentional failure")
+	}
+	return process.User, nil
+}
+
+func (s ProciMock) GetProcessParentPid(pid uint32) (uint32, error) {
+	process, err := s.process(pid)
+	if err != nil {
+		return 0, err
+	}
+	if process.DoFailParentPid {
+		return 0, fmt.Errorf("GetProcessParentPid Mock intentional failure")
+	}
+	return process.ParentPid, nil
+}
+
+func (s ProciMock) GetProcessCPUTimes(pid uint32) (*CPUTimes, error) {
+	process, err := s.process(pid)
+	if err != nil {
+		return nil, err
+	}
+	if process.DoFailCPUTimes {
+		return nil, fmt.Errorf("GetProcessCPUTimes Mock intentional failure")
+	}
+	if len(process.CPUTimes) == 0 {
+		return &CPUTimes{}, nil
+	}
+	index := process.cpuTimesCalls
+	if index >= len(process.CPUTimes) {
+		index = len(process.CPUTimes) - 1
+	}
+	process.cpuTimesCalls++
+	times := process.CPUTimes[index]
+	return &times, nil
+}
+
+func (s ProciMock) GetProcessIOCounters(pid uint32) (*IOCounters, error) {
+	process, err := s.process(pid)
+	if err != nil {
+		return nil, err
+	}
+	if process.DoFailIOCounters {
+		return nil, fmt.Errorf("GetProcessIOCounters Mock intentional failure")
+	}
+	if len(process.IOCounters) == 0 {
+		return &IOCounters{}, nil
+	}
+	index := process.ioCountersCalls
+	if index >= len(process.IOCounters) {
+		index = len(process.IOCounters) - 1
+	}
+	process.ioCountersCalls++
+	counters := process.IOCounters[index]
+	return &counters, nil
+}
+
+func (s ProciMock) GetProcessStartTime(pid uint32) (time.Time, error) {
+	process, err := s.process(pid)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if process.DoFailStartTime {
+		return time.Time{}, fmt.Errorf("GetProcessStartTime Mock intentional failure")
+	}
+	return process.StartTime, nil
+}
+
+func (s ProciMock) GetProcessKey(pid uint32) (ProcessKey, error) {
+	startTime, err := s.GetProcessStartTime(pid)
+	if err != nil {
+		return ProcessKey{}, err
+	}
+	return newProcessKey(pid, startTime), nil
+}
+
+func (s ProciMock) GetProcessState(pid uint32) (State, error) {
+	process, err := s.process(pid)
+	if err != nil {
+		return StateUnknown, err
+	}
+	if process.DoFailState {
+		return StateUnknown, fmt.Errorf("GetProcessState Mock intentional failure")
+	}
+	return process.State, nil
+}
+
+func (s ProciMock) GetProcessThreads(pid uint32) ([]Thread, error) {
+	process, err := s.process(pid)
+	if err != nil {
+		return nil, err
+	}
+	if process.DoFailThreads {
+		return nil, fmt.Errorf("GetProcessThreads Mock intentional failure")
+	}
+	return process.Threads, nil
+}
+
+func (s ProciMock) GetProcessOpenFiles(pid uint32) ([]OpenFile, error) {
+	process, err := s.process(pid)
+	if err != nil {
+		return nil, err
+	}
+	if process.DoFailOpenFiles {
+		return nil, fmt.Errorf("GetProcessOpenFiles Mock intentional failure")
+	}
+	return process.OpenFiles, nil
+}
+
+func (s ProciMock) GetProcessFdCount(pid uint32) (int, error) {
+	process, err := s.process(pid)
+	if err != nil {
+		return 0, err
+	}
+	if process.DoFailOpenFiles {
+		return 0, fmt.Errorf("GetProcessFdCount Mock intentional failure")
+	}
+	return len(process.OpenFiles), nil
+}
+
+func (s ProciMock) GetProcessConnections(pid uint32) ([]Connection, error) {
+	process, err := s.process(pid)
+	if err != nil {
+		return nil, err
+	}
+	if process.DoFailConnections {
+		return nil, fmt.Errorf("GetProcessConnections Mock intentional failure")
+	}
+	connections := make([]Connection, len(process.Connections))
+	for i, connection := range process.Connections {
+		connection.Pid = pid
+		connections[i] = connection
+	}
+	return connections, nil
+}
+
+func (s ProciMock) GetProcessLimits(pid uint32) (map[string]Limit, error) {
+	process, err := s.process(pid)
+	if err != nil {
+		return nil, err
+	}
+	if process.DoFailLimits {
+		return nil, fmt.Errorf("GetProcessLimits Mock intentional failure")
+	}
+	return process.Limits, nil
+}
+
+func (s ProciMock) GetProcessCgroups(pid uint32) ([]Cgroup, error) {
+	process, err := s.process(pid)
+	if err != nil {
+		return nil, err
+	}
+	if process.DoFailCgroups {
+		return nil, fmt.Errorf("GetProcessCgroups Mock intentional failure")
+	}
+	return process.Cgroups, nil
+}
+
+func (s ProciMock) GetProcessNamespaces(pid uint32) (*Namespaces, error) {
+	process, err := s.process(pid)
+	if err != nil {
+		return nil, err
+	}
+	if process.DoFailNamespaces {
+		return nil, fmt.Errorf("GetProcessNamespaces Mock intentional failure")
+	}
+	return process.Namespaces, nil
+}
+
+func (s ProciMock) CgroupStats(path string) (*CgroupResourceStats, error) {
+	if s.DoFailCgroupStats {
+		return nil, fmt.Errorf("CgroupStats Mock intentional failure")
+	}
+	stats, hasPath := s.CgroupStatsByPath[path]
+	if !hasPath {
+		return nil, fmt.Errorf("cgroup %s does not exist", path)
+	}
+	return stats, nil
+}
+
+func (s ProciMock) Connections() ([]Connection, error) {
+	if s.DoFailConnections {
+		return nil, fmt.Errorf("Connections Mock intentional failure")
+	}
+	pids, _ := s.ListProcessPids()
+	var connections []Connection
+	for _, pid := range pids {
+		if processConnections, err := s.GetProcessConnections(pid); err == nil {
+			connections = append(connections, processConnections...)
+		}
+	}
+	return connections, nil
+}
+
+func (s ProciMock) GetProcess(pid uint32) (*Process, error) {
+	if _, err := s.process(pid); err != nil {
+		return nil, err
+	}
+	process := &Process{Pid: pid}
+	process.ParentPid, process.ParentPidErr = s.GetProcessParentPid(pid)
+	process.Path, process.PathErr = s.GetProcessPath(pid)
+	if process.Path != "" {
+		process.Name = filepath.Base(process.Path)
+	}
+	process.CommandLine, process.CommandLineErr = s.GetProcessCommandLine(pid)
+	process.MemoryUsage, process.MemoryUsageErr = s.GetProcessMemoryUsage(pid)
+	process.User, process.UserErr = s.GetProcessUser(pid)
+	process.StartTime, process.StartTimeErr = s.GetProcessStartTime(pid)
+	process.State, process.StateErr = s.GetProcessState(pid)
+	process.Cgroups, process.CgroupsErr = s.GetProcessCgroups(pid)
+	process.Namespaces, process.NamespacesErr = s.GetProcessNamespaces(pid)
+	if threads, err := s.GetProcessThreads(pid); err == nil {
+		process.ThreadCount = len(threads)
+	} else {
+		process.ThreadCountErr = err
+	}
+	return process, nil
+}
+
+func (s ProciMock) Snapshot() (*SystemSnapshot, error) {
+	return takeSnapshot(s)
+}
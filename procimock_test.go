@@ -2,7 +2,9 @@
 package proci
 
 import (
+	"errors"
 	"testing"
+	"time"
 )
 
 func TestMock(t *testing.T) {
@@ -11,6 +13,14 @@ func TestMock(t *testing.T) {
 	if nbrProcesses != 10 {
 		t.Fatalf("Expected 10 mocked processes but it was %d", nbrProcesses)
 	}
+	pm.DoFailPids = true
+	if _, errPids := pm.ListProcessPids(); errPids == nil {
+		t.Fatal("Expected error for ListProcessPids")
+	}
+	if pids := pm.GetProcessPids(); pids != nil {
+		t.Fatal("Expected nil from GetProcessPids when failing")
+	}
+	pm.DoFailPids = false
 	
 	// GetProcessMemoryUsage
 	mem, errMem := pm.GetProcessMemoryUsage(8)
@@ -26,8 +36,8 @@ func TestMock(t *testing.T) {
 		t.Fatal("Expected error for GetProcessMemoryUsage")
 	}
 	_, errMem3 := pm.GetProcessMemoryUsage(1234)
-	if errMem3 == nil {
-		t.Fatal("Expected error for GetProcessMemoryUsage for invalid PID")
+	if !errors.Is(errMem3, ErrProcessNotFound) {
+		t.Fatal("Expected ErrProcessNotFound for GetProcessMemoryUsage for invalid PID")
 	}
 	
 	// GetProcessPath
@@ -44,8 +54,8 @@ func TestMock(t *testing.T) {
 		t.Fatal("Expected error for GetProcessPath")
 	}
 	_, errPath3 := pm.GetProcessPath(1234)
-	if errPath3 == nil {
-		t.Fatal("Expected error for GetProcessPath for invalid PID")
+	if !errors.Is(errPath3, ErrProcessNotFound) {
+		t.Fatal("Expected ErrProcessNotFound for GetProcessPath for invalid PID")
 	}
 	
 	// GetProcessCommandLine
@@ -62,8 +72,8 @@ func TestMock(t *testing.T) {
 		t.Fatal("Expected error for GetProcessCommandLine")
 	}
 	_, errCmd3 := pm.GetProcessCommandLine(1234)
-	if errCmd3 == nil {
-		t.Fatal("Expected error for GetProcessCommandLine for invalid PID")
+	if !errors.Is(errCmd3, ErrProcessNotFound) {
+		t.Fatal("Expected ErrProcessNotFound for GetProcessCommandLine for invalid PID")
 	}
 	
 	// GetMemoryStatus
@@ -87,3 +97,387 @@ func TestMock(t *testing.T) {
 	
 }
 
+func TestMockGetProcess(t *testing.T) {
+	pm := GenerateMock(10)
+	pm.Processes[5].DoFailCommandLine = true
+	process, err := pm.GetProcess(5)
+	if err != nil {
+		t.Fatalf("Expected no error for GetProcess but it was %s", err)
+	}
+	if process.Pid != 5 || process.Path != "path_5" || process.Name != "path_5" {
+		t.Fatalf("Unexpected process %+v", process)
+	}
+	if process.MemoryUsage != 1024+1024*5 || process.MemoryUsageErr != nil {
+		t.Fatalf("Unexpected memory usage %d (%v)", process.MemoryUsage, process.MemoryUsageErr)
+	}
+	if process.CommandLineErr == nil {
+		t.Fatal("Expected CommandLineErr to be set")
+	}
+	_, err = pm.GetProcess(1234)
+	if !errors.Is(err, ErrProcessNotFound) {
+		t.Fatal("Expected ErrProcessNotFound for GetProcess for invalid PID")
+	}
+}
+
+func TestMockSnapshot(t *testing.T) {
+	pm := GenerateMock(10)
+	pm.Processes[4].DoExit = true
+	snapshot, err := pm.Snapshot()
+	if err != nil {
+		t.Fatalf("Expected no error for Snapshot but it was %s", err)
+	}
+	if snapshot.Time.IsZero() {
+		t.Fatal("Expected snapshot time to be set")
+	}
+	if snapshot.MemoryStatus != pm.MemStatus {
+		t.Fatal("Expected snapshot memory status to be the mocked one")
+	}
+	if len(snapshot.Processes) != 10 {
+		t.Fatalf("Expected 10 processes in snapshot but it was %d", len(snapshot.Processes))
+	}
+	for i, process := range snapshot.Processes {
+		if process.Pid != uint32(i) {
+			t.Fatalf("Expected PID %d at index %d but it was %d", i, i, process.Pid)
+		}
+		if process.Exited != (i == 4) {
+			t.Fatalf("Unexpected Exited %t for PID %d", process.Exited, process.Pid)
+		}
+	}
+	if !errors.Is(snapshot.Processes[4].PathErr, ErrProcessNotFound) {
+		t.Fatal("Expected ErrProcessNotFound for exited process in snapshot")
+	}
+	if snapshot.Processes[5].Path != "path_5" {
+		t.Fatalf("Expected path path_5 but it was %s", snapshot.Processes[5].Path)
+	}
+
+	pm.DoFailMemStatus = true
+	if _, err = pm.Snapshot(); err == nil {
+		t.Fatal("Expected error for Snapshot when memory status fails")
+	}
+	pm.DoFailMemStatus = false
+	pm.DoFailPids = true
+	if _, err = pm.Snapshot(); err == nil {
+		t.Fatal("Expected error for Snapshot when listing PIDs fails")
+	}
+}
+
+func TestMockGetProcessCPUTimes(t *testing.T) {
+	pm := GenerateMock(2)
+	times, err := pm.GetProcessCPUTimes(1)
+	if err != nil || times.Total() != 0 {
+		t.Fatal("Expected zero CPU times when nothing is scripted")
+	}
+	pm.Processes[1].CPUTimes = []CPUTimes{{User: 1}, {User: 2}}
+	for _, expected := range []time.Duration{1, 2, 2} {
+		times, err = pm.GetProcessCPUTimes(1)
+		if err != nil {
+			t.Fatalf("Expected no error for GetProcessCPUTimes but it was %s", err)
+		}
+		if times.User != expected {
+			t.Fatalf("Expected scripted user time %d but it was %d", expected, times.User)
+		}
+	}
+	pm.Processes[1].DoFailCPUTimes = true
+	if _, err = pm.GetProcessCPUTimes(1); err == nil {
+		t.Fatal("Expected error for GetProcessCPUTimes")
+	}
+}
+
+func TestMockGetProcessMemoryInfo(t *testing.T) {
+	pm := GenerateMock(2)
+	memoryInfo, err := pm.GetProcessMemoryInfo(1)
+	if err != nil {
+		t.Fatalf("Expected no error for GetProcessMemoryInfo but it was %s", err)
+	}
+	if memoryInfo.RSS != 2048 {
+		t.Fatalf("Expected RSS 2048 but it was %d", memoryInfo.RSS)
+	}
+	pm.Processes[1].MemoryInfo = &MemoryInfo{RSS: 10, PSS: 5}
+	memoryInfo, _ = pm.GetProcessMemoryInfo(1)
+	if memoryInfo.RSS != 10 || memoryInfo.PSS != 5 {
+		t.Fatalf("Expected mocked memory info but it was %+v", memoryInfo)
+	}
+	pm.Processes[1].DoFailMemoryInfo = true
+	if _, err = pm.GetProcessMemoryInfo(1); err == nil {
+		t.Fatal("Expected error for GetProcessMemoryInfo")
+	}
+}
+
+func TestMockGetMemoryStatusEx(t *testing.T) {
+	pm := GenerateMock(1)
+	mStatEx, err := pm.GetMemoryStatusEx()
+	if err != nil {
+		t.Fatalf("Expected no error for GetMemoryStatusEx but it was %s", err)
+	}
+	if mStatEx.MemoryStatus != *pm.MemStatus || mStatEx.SwapTotal != 0 {
+		t.Fatalf("Expected extended mocked memory status but it was %+v", mStatEx)
+	}
+	pm.MemStatusEx = &MemoryStatusEx{SwapTotal: 100}
+	mStatEx, _ = pm.GetMemoryStatusEx()
+	if mStatEx.SwapTotal != 100 {
+		t.Fatalf("Expected mocked SwapTotal 100 but it was %d", mStatEx.SwapTotal)
+	}
+	pm.DoFailMemStatus = true
+	if _, err = pm.GetMemoryStatusEx(); err == nil {
+		t.Fatal("Expected error for GetMemoryStatusEx")
+	}
+}
+
+func TestMockGetProcessArgs(t *testing.T) {
+	pm := GenerateMock(2)
+	args, err := pm.GetProcessArgs(1)
+	if err != nil {
+		t.Fatalf("Expected no error for GetProcessArgs but it was %s", err)
+	}
+	if len(args) != 1 || args[0] != "command_line_1" {
+		t.Fatalf("Unexpected arguments %q", args)
+	}
+	pm.Processes[1].DoFailArgs = true
+	if _, err = pm.GetProcessArgs(1); err == nil {
+		t.Fatal("Expected error for GetProcessArgs")
+	}
+}
+
+func TestMockGetProcessEnviron(t *testing.T) {
+	pm := GenerateMock(2)
+	environ, err := pm.GetProcessEnviron(1)
+	if err != nil {
+		t.Fatalf("Expected no error for GetProcessEnviron but it was %s", err)
+	}
+	if environ["PID"] != "1" {
+		t.Fatalf("Unexpected environment %v", environ)
+	}
+	pm.Processes[1].DoFailEnviron = true
+	if _, err = pm.GetProcessEnviron(1); !errors.Is(err, ErrAccessDenied) {
+		t.Fatal("Expected ErrAccessDenied for GetProcessEnviron")
+	}
+}
+
+func TestMockGetProcessCwd(t *testing.T) {
+	pm := GenerateMock(2)
+	cwd, err := pm.GetProcessCwd(1)
+	if err != nil {
+		t.Fatalf("Expected no error for GetProcessCwd but it was %s", err)
+	}
+	if cwd != "cwd_1" {
+		t.Fatalf("Expected cwd_1 but it was %s", cwd)
+	}
+	pm.Processes[1].DoFailCwd = true
+	if _, err = pm.GetProcessCwd(1); err == nil {
+		t.Fatal("Expected error for GetProcessCwd")
+	}
+}
+
+func TestMockGetProcessUser(t *testing.T) {
+	pm := GenerateMock(2)
+	user, err := pm.GetProcessUser(1)
+	if err != nil {
+		t.Fatalf("Expected no error for GetProcessUser but it was %s", err)
+	}
+	if user.Username != "user" {
+		t.Fatalf("Unexpected user %+v", user)
+	}
+	pm.Processes[1].DoFailUser = true
+	if _, err = pm.GetProcessUser(1); err == nil {
+		t.Fatal("Expected error for GetProcessUser")
+	}
+}
+
+func TestMockGetProcessStartTime(t *testing.T) {
+	pm := GenerateMock(3)
+	first, err := pm.GetProcessStartTime(1)
+	if err != nil {
+		t.Fatalf("Expected no error for GetProcessStartTime but it was %s", err)
+	}
+	second, _ := pm.GetProcessStartTime(2)
+	if second.Sub(first) != time.Second {
+		t.Errorf("Expected processes started one second apart but it was %s and %s", first, second)
+	}
+
+	// A reused PID gets a new key
+	process, _ := pm.GetProcess(1)
+	key := process.Key()
+	if processKey, err := pm.GetProcessKey(1); err != nil || processKey != key {
+		t.Errorf("Expected key %+v but it was %+v, error %v", key, processKey, err)
+	}
+	pm.Processes[1].StartTime = pm.Processes[1].StartTime.Add(time.Hour)
+	process, _ = pm.GetProcess(1)
+	if process.Key() == key {
+		t.Error("Expected a different key for a reused PID")
+	}
+
+	pm.Processes[1].DoFailStartTime = true
+	if _, err = pm.GetProcessStartTime(1); err == nil {
+		t.Fatal("Expected error for GetProcessStartTime")
+	}
+}
+
+func TestMockGetProcessState(t *testing.T) {
+	pm := GenerateMock(2)
+	state, err := pm.GetProcessState(1)
+	if err != nil {
+		t.Fatalf("Expected no error for GetProcessState but it was %s", err)
+	}
+	if state != StateSleeping {
+		t.Errorf("Expected state sleeping but it was %s", state)
+	}
+	pm.Processes[1].DoFailState = true
+	if _, err = pm.GetProcessState(1); err == nil {
+		t.Fatal("Expected error for GetProcessState")
+	}
+}
+
+func TestMockGetProcessThreads(t *testing.T) {
+	pm := GenerateMock(2)
+	pm.Processes[1].Threads = append(pm.Processes[1].Threads, Thread{Tid: 100, Name: "worker"})
+	threads, err := pm.GetProcessThreads(1)
+	if err != nil {
+		t.Fatalf("Expected no error for GetProcessThreads but it was %s", err)
+	}
+	if len(threads) != 2 || threads[0].Tid != 1 || threads[1].Name != "worker" {
+		t.Errorf("Unexpected threads %+v", threads)
+	}
+	process, _ := pm.GetProcess(1)
+	if process.ThreadCount != 2 {
+		t.Errorf("Expected thread count 2 but it was %d", process.ThreadCount)
+	}
+	pm.Processes[1].DoFailThreads = true
+	if _, err = pm.GetProcessThreads(1); err == nil {
+		t.Fatal("Expected error for GetProcessThreads")
+	}
+	if process, _ = pm.GetProcess(1); process.ThreadCountErr == nil {
+		t.Error("Expected ThreadCountErr to be set")
+	}
+}
+
+func TestMockGetProcessOpenFiles(t *testing.T) {
+	pm := GenerateMock(2)
+	pm.Processes[1].OpenFiles = append(pm.Processes[1].OpenFiles, OpenFile{Fd: 3, Path: "socket:[1]", Type: FileTypeSocket})
+	files, err := pm.GetProcessOpenFiles(1)
+	if err != nil {
+		t.Fatalf("Expected no error for GetProcessOpenFiles but it was %s", err)
+	}
+	if len(files) != 2 || files[1].Type != FileTypeSocket {
+		t.Errorf("Unexpected open files %+v", files)
+	}
+	if count, _ := pm.GetProcessFdCount(1); count != 2 {
+		t.Errorf("Expected 2 file descriptors but it was %d", count)
+	}
+	pm.Processes[1].DoFailOpenFiles = true
+	if _, err = pm.GetProcessOpenFiles(1); err == nil {
+		t.Fatal("Expected error for GetProcessOpenFiles")
+	}
+	if _, err = pm.GetProcessFdCount(1); err == nil {
+		t.Fatal("Expected error for GetProcessFdCount")
+	}
+}
+
+func TestMockConnections(t *testing.T) {
+	pm := GenerateMock(3)
+	pm.Processes[1].Connections = []Connection{{Protocol: "tcp", State: "LISTEN", Inode: 1}}
+	pm.Processes[2].Connections = []Connection{{Protocol: "udp", Inode: 2}, {Protocol: "unix", Inode: 3}}
+	connections, err := pm.GetProcessConnections(1)
+	if err != nil {
+		t.Fatalf("Expected no error for GetProcessConnections but it was %s", err)
+	}
+	if len(connections) != 1 || connections[0].Pid != 1 {
+		t.Errorf("Unexpected connections %+v", connections)
+	}
+	if connections, _ = pm.Connections(); len(connections) != 3 || connections[2].Pid != 2 {
+		t.Errorf("Unexpected connections %+v", connections)
+	}
+	pm.Processes[1].DoFailConnections = true
+	if _, err = pm.GetProcessConnections(1); err == nil {
+		t.Fatal("Expected error for GetProcessConnections")
+	}
+	pm.DoFailConnections = true
+	if _, err = pm.Connections(); err == nil {
+		t.Fatal("Expected error for Connections")
+	}
+}
+
+func TestMockGetProcessIOCounters(t *testing.T) {
+	pm := GenerateMock(2)
+	counters, err := pm.GetProcessIOCounters(1)
+	if err != nil {
+		t.Fatalf("Expected no error for GetProcessIOCounters but it was %s", err)
+	}
+	if *counters != (IOCounters{}) {
+		t.Errorf("Expected zero counters but it was %+v", *counters)
+	}
+	pm.Processes[1].IOCounters = []IOCounters{{ReadBytes: 1}, {ReadBytes: 2}}
+	for _, expected := range []uint64{1, 2, 2} {
+		if counters, _ = pm.GetProcessIOCounters(1); counters.ReadBytes != expected {
+			t.Errorf("Expected %d read bytes but it was %d", expected, counters.ReadBytes)
+		}
+	}
+	pm.Processes[1].DoFailIOCounters = true
+	if _, err = pm.GetProcessIOCounters(1); err == nil {
+		t.Fatal("Expected error for GetProcessIOCounters")
+	}
+}
+
+func TestMockGetProcessLimits(t *testing.T) {
+	pm := GenerateMock(2)
+	limits, err := pm.GetProcessLimits(1)
+	if err != nil {
+		t.Fatalf("Expected no error for GetProcessLimits but it was %s", err)
+	}
+	if limits[LimitOpenFiles].Soft != 1024 || limits[LimitAddressSpace].Hard != LimitUnlimited {
+		t.Errorf("Unexpected limits %+v", limits)
+	}
+	pm.Processes[1].DoFailLimits = true
+	if _, err = pm.GetProcessLimits(1); err == nil {
+		t.Fatal("Expected error for GetProcessLimits")
+	}
+}
+
+func TestMockGetProcessCgroups(t *testing.T) {
+	pm := GenerateMock(2)
+	cgroups, err := pm.GetProcessCgroups(1)
+	if err != nil {
+		t.Fatalf("Expected no error for GetProcessCgroups but it was %s", err)
+	}
+	if len(cgroups) != 1 || cgroups[0].Path != "/user.slice" {
+		t.Errorf("Unexpected cgroups %+v", cgroups)
+	}
+	pm.Processes[1].DoFailCgroups = true
+	if _, err = pm.GetProcessCgroups(1); err == nil {
+		t.Fatal("Expected error for GetProcessCgroups")
+	}
+}
+
+func TestMockGetProcessNamespaces(t *testing.T) {
+	pm := GenerateMock(2)
+	pm.Processes[1].Namespaces.Net = 1
+	namespaces, err := pm.GetProcessNamespaces(1)
+	if err != nil {
+		t.Fatalf("Expected no error for GetProcessNamespaces but it was %s", err)
+	}
+	if namespaces.Net != 1 {
+		t.Errorf("Unexpected namespaces %+v", namespaces)
+	}
+	if namespaces, _ = pm.GetProcessNamespaces(0); namespaces.Net == 1 {
+		t.Error("Expected the processes to have separate namespaces")
+	}
+	pm.Processes[1].DoFailNamespaces = true
+	if _, err = pm.GetProcessNamespaces(1); err == nil {
+		t.Fatal("Expected error for GetProcessNamespaces")
+	}
+}
+
+func TestMockGetProcessMemoryMaps(t *testing.T) {
+	pm := GenerateMock(2)
+	pm.Processes[1].MemoryMaps = []MemoryMap{{StartAddr: 0x1000, EndAddr: 0x2000, Path: "[heap]", Size: 0x1000}}
+	maps, err := pm.GetProcessMemoryMaps(1)
+	if err != nil {
+		t.Fatalf("Expected no error for GetProcessMemoryMaps but it was %s", err)
+	}
+	if len(maps) != 1 || maps[0].Path != "[heap]" {
+		t.Errorf("Unexpected memory maps %+v", maps)
+	}
+	pm.Processes[1].DoFailMemoryMaps = true
+	if _, err = pm.GetProcessMemoryMaps(1); err == nil {
+		t.Fatal("Expected error for GetProcessMemoryMaps")
+	}
+}
@@ -0,0 +1,53 @@
+package proci
+
+import (
+	"time"
+)
+
+// sampler keeps the previous sample of each process, for the samplers that
+// calculate rates between two samples, such as CPUSampler and IOSampler.
+type sampler[T any] struct {
+	read     func(pid uint32) (*T, error)
+	previous map[uint32]sample[T]
+	now      func() time.Time // Replaced in tests
+}
+
+type sample[T any] struct {
+	value T
+	time  time.Time
+}
+
+// Creates a sampler that reads the samples with the provided function.
+func newSampler[T any](read func(pid uint32) (*T, error)) sampler[T] {
+	return sampler[T]{
+		read:     read,
+		previous: make(map[uint32]sample[T]),
+		now:      time.Now}
+}
+
+// Reads a new sample of the process and returns it together with the
+// previous sample and the time between them. ok is false if there is
+// nothing to compare with, i.e. for the first sample of a process or if no
+// time has passed. If the process cannot be read it is forgotten and the
+// error is returned.
+func (s *sampler[T]) next(pid uint32) (previous, current T, elapsed time.Duration, ok bool, err error) {
+	value, err := s.read(pid)
+	if err != nil {
+		delete(s.previous, pid)
+		return previous, current, 0, false, err
+	}
+	currentSample := sample[T]{value: *value, time: s.now()}
+	previousSample, hasPrevious := s.previous[pid]
+	s.previous[pid] = currentSample
+	elapsed = currentSample.time.Sub(previousSample.time)
+	if !hasPrevious || elapsed <= 0 {
+		return previous, currentSample.value, 0, false, nil
+	}
+	return previousSample.value, currentSample.value, elapsed, true, nil
+}
+
+// Forget removes the previous sample of a process, for example when it has
+// exited.
+func (s *sampler[T]) Forget(pid uint32) {
+	delete(s.previous, pid)
+}
@@ -0,0 +1,35 @@
+// proci sampler unit tests
+package proci
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSampler(t *testing.T) {
+	pm := GenerateMock(2)
+	pm.Processes[1].CPUTimes = []CPUTimes{{User: time.Second}, {User: 2 * time.Second}, {User: 3 * time.Second}}
+
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	s := newSampler(pm.GetProcessCPUTimes)
+	s.now = func() time.Time { return now }
+
+	if _, _, _, ok, err := s.next(1); ok || err != nil {
+		t.Errorf("Expected nothing to compare with for first sample but it was %v, error %v", ok, err)
+	}
+	// No time has passed
+	if _, _, _, ok, _ := s.next(1); ok {
+		t.Error("Expected nothing to compare with when no time has passed")
+	}
+
+	now = now.Add(time.Second)
+	previous, current, elapsed, ok, err := s.next(1)
+	if !ok || err != nil || previous.User != 2*time.Second || current.User != 3*time.Second || elapsed != time.Second {
+		t.Errorf("Unexpected sample %+v to %+v in %s, ok %v, error %v", previous, current, elapsed, ok, err)
+	}
+
+	s.Forget(1)
+	if _, found := s.previous[1]; found {
+		t.Error("Expected the process to be forgotten")
+	}
+}
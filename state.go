@@ -0,0 +1,47 @@
+package proci
+
+// State is the run state of a process.
+type State int
+
+const (
+	StateUnknown   State = iota // The state could not be mapped to any of the below
+	StateRunning                // Running or runnable
+	StateSleeping               // Interruptible sleep, waiting for an event
+	StateDiskSleep              // Uninterruptible sleep, usually waiting for I/O
+	StateStopped                // Stopped by a signal or a debugger
+	StateZombie                 // Exited but not yet reaped by its parent
+	StateDead                   // Exited and being removed
+	StateIdle                   // Idle kernel thread
+)
+
+var stateNames = [...]string{
+	StateUnknown:   "unknown",
+	StateRunning:   "running",
+	StateSleeping:  "sleeping",
+	StateDiskSleep: "disk sleep",
+	StateStopped:   "stopped",
+	StateZombie:    "zombie",
+	StateDead:      "dead",
+	StateIdle:      "idle",
+}
+
+// String returns the name of the state, such as "running".
+func (s State) String() string {
+	if s < 0 || int(s) >= len(stateNames) {
+		return stateNames[StateUnknown]
+	}
+	return stateNames[s]
+}
+
+// FindZombies returns the zombie processes in a snapshot, sorted by PID. The
+// ParentPid of each zombie is the process that is expected to reap it.
+// Processes whose state could not be read are not included.
+func FindZombies(snapshot *SystemSnapshot) []Process {
+	var zombies []Process
+	for _, process := range snapshot.Processes {
+		if process.StateErr == nil && process.State == StateZombie {
+			zombies = append(zombies, process)
+		}
+	}
+	return zombies
+}
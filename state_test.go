@@ -0,0 +1,47 @@
+// proci process state unit tests
+package proci
+
+import (
+	"testing"
+)
+
+func TestStateString(t *testing.T) {
+	tests := []struct {
+		state    State
+		expected string
+	}{
+		{StateRunning, "running"},
+		{StateDiskSleep, "disk sleep"},
+		{StateZombie, "zombie"},
+		{StateIdle, "idle"},
+		{State(-1), "unknown"},
+		{State(100), "unknown"},
+	}
+	for _, test := range tests {
+		if test.state.String() != test.expected {
+			t.Errorf("Expected %q but it was %q", test.expected, test.state.String())
+		}
+	}
+}
+
+func TestFindZombies(t *testing.T) {
+	pm := GenerateMock(6)
+	pm.Processes[2].State = StateZombie
+	pm.Processes[2].ParentPid = 1
+	pm.Processes[4].State = StateZombie
+	pm.Processes[4].ParentPid = 3
+	pm.Processes[5].State = StateZombie
+	pm.Processes[5].DoFailState = true
+
+	snapshot, err := pm.Snapshot()
+	if err != nil {
+		t.Fatalf("Snapshot returned error: %s", err)
+	}
+	zombies := FindZombies(snapshot)
+	if len(zombies) != 2 {
+		t.Fatalf("Expected 2 zombies but it was %d", len(zombies))
+	}
+	if zombies[0].Pid != 2 || zombies[0].ParentPid != 1 || zombies[1].Pid != 4 || zombies[1].ParentPid != 3 {
+		t.Errorf("Unexpected zombies %+v", zombies)
+	}
+}
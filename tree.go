@@ -0,0 +1,162 @@
+package proci
+
+import (
+	"sort"
+)
+
+// ProcessTree holds the parent/child relationships between the processes in
+// a snapshot. Create it with BuildTree.
+type ProcessTree struct {
+	Roots []*ProcessNode // Processes without a parent in the tree, sorted by PID
+
+	nodes map[uint32]*ProcessNode
+}
+
+// ProcessNode is a process in a ProcessTree.
+type ProcessNode struct {
+	Process  *Process
+	Parent   *ProcessNode   // nil if the process is a root
+	Children []*ProcessNode // Sorted by PID
+}
+
+// BuildTree builds a process tree from the processes in a snapshot. Processes
+// that exited during the snapshot, or whose parent PID could not be read, are
+// not included.
+//
+// A process is a root in the tree if its parent is not in the snapshot or if
+// it is its own parent (such as the idle process on Windows). If the parent
+// PIDs form a cycle, which can happen if PIDs have been reused, the process
+// with the lowest PID in the cycle is made a root.
+func BuildTree(snapshot *SystemSnapshot) *ProcessTree {
+	tree := &ProcessTree{nodes: make(map[uint32]*ProcessNode)}
+	pids := make([]uint32, 0, len(snapshot.Processes))
+	for i := range snapshot.Processes {
+		process := &snapshot.Processes[i]
+		if process.Exited || process.ParentPidErr != nil {
+			continue
+		}
+		tree.nodes[process.Pid] = &ProcessNode{Process: process}
+		pids = append(pids, process.Pid)
+	}
+	sort.Slice(pids, func(i, j int) bool { return pids[i] < pids[j] })
+
+	for _, pid := range pids {
+		node := tree.nodes[pid]
+		parent, hasParent := tree.nodes[node.Process.ParentPid]
+		if !hasParent || parent == node {
+			tree.Roots = append(tree.Roots, node)
+			continue
+		}
+		node.Parent = parent
+		parent.Children = append(parent.Children, node)
+	}
+
+	// Nodes in a cycle cannot be reached from any root
+	reached := make(map[uint32]bool)
+	for _, root := range tree.Roots {
+		root.walk(func(node *ProcessNode) { reached[node.Process.Pid] = true })
+	}
+	for _, pid := range pids {
+		if reached[pid] {
+			continue
+		}
+		// Follow the parents until a node is visited twice, that node is in
+		// the cycle
+		node := tree.nodes[pid]
+		visited := make(map[*ProcessNode]bool)
+		for !visited[node] {
+			visited[node] = true
+			node = node.Parent
+		}
+		lowest := node
+		for n := node.Parent; n != node; n = n.Parent {
+			if n.Process.Pid < lowest.Process.Pid {
+				lowest = n
+			}
+		}
+		lowest.Parent.removeChild(lowest)
+		lowest.Parent = nil
+		tree.Roots = append(tree.Roots, lowest)
+		lowest.walk(func(node *ProcessNode) { reached[node.Process.Pid] = true })
+	}
+	sort.Slice(tree.Roots, func(i, j int) bool {
+		return tree.Roots[i].Process.Pid < tree.Roots[j].Process.Pid
+	})
+	return tree
+}
+
+// Node returns the node of a process, or nil if the process is not in the
+// tree.
+func (t *ProcessTree) Node(pid uint32) *ProcessNode {
+	return t.nodes[pid]
+}
+
+// Children returns the PIDs of the processes that the process has started.
+func (t *ProcessTree) Children(pid uint32) []uint32 {
+	node := t.nodes[pid]
+	if node == nil {
+		return nil
+	}
+	children := make([]uint32, 0, len(node.Children))
+	for _, child := range node.Children {
+		children = append(children, child.Process.Pid)
+	}
+	return children
+}
+
+// Descendants returns the PIDs of the children of the process, their
+// children and so on. The process itself is not included.
+func (t *ProcessTree) Descendants(pid uint32) []uint32 {
+	node := t.nodes[pid]
+	if node == nil {
+		return nil
+	}
+	var descendants []uint32
+	node.walk(func(descendant *ProcessNode) {
+		if descendant != node {
+			descendants = append(descendants, descendant.Process.Pid)
+		}
+	})
+	return descendants
+}
+
+// Ancestors returns the PIDs of the parent of the process, its parent and so
+// on up to the root. The process itself is not included.
+func (t *ProcessTree) Ancestors(pid uint32) []uint32 {
+	node := t.nodes[pid]
+	if node == nil {
+		return nil
+	}
+	var ancestors []uint32
+	for ancestor := node.Parent; ancestor != nil; ancestor = ancestor.Parent {
+		ancestors = append(ancestors, ancestor.Process.Pid)
+	}
+	return ancestors
+}
+
+// TotalMemoryUsage returns the sum of the memory usage of the process and
+// all its descendants.
+func (n *ProcessNode) TotalMemoryUsage() uint64 {
+	var total uint64
+	n.walk(func(node *ProcessNode) {
+		total += node.Process.MemoryUsage
+	})
+	return total
+}
+
+// Calls fn for the node and all its descendants, parents before children.
+func (n *ProcessNode) walk(fn func(*ProcessNode)) {
+	fn(n)
+	for _, child := range n.Children {
+		child.walk(fn)
+	}
+}
+
+func (n *ProcessNode) removeChild(child *ProcessNode) {
+	for i, c := range n.Children {
+		if c == child {
+			n.Children = append(n.Children[:i], n.Children[i+1:]...)
+			return
+		}
+	}
+}
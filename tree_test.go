@@ -0,0 +1,91 @@
+// proci process tree unit tests
+package proci
+
+import (
+	"reflect"
+	"testing"
+)
+
+// Mock with the tree:
+//
+//	0
+//	├── 1
+//	│   ├── 3
+//	│   └── 4
+//	│       └── 6
+//	└── 2
+//	    └── 5
+//	7 (parent 99 does not exist)
+func treeMock() *ProciMock {
+	pm := GenerateMock(8)
+	parents := map[uint32]uint32{0: 0, 1: 0, 2: 0, 3: 1, 4: 1, 5: 2, 6: 4, 7: 99}
+	for pid, parentPid := range parents {
+		pm.Processes[pid].ParentPid = parentPid
+	}
+	return pm
+}
+
+func TestBuildTree(t *testing.T) {
+	snapshot, err := treeMock().Snapshot()
+	if err != nil {
+		t.Fatalf("Snapshot returned error: %s", err)
+	}
+	tree := BuildTree(snapshot)
+	if len(tree.Roots) != 2 || tree.Roots[0].Process.Pid != 0 || tree.Roots[1].Process.Pid != 7 {
+		t.Fatalf("Expected roots 0 and 7 but it was %v", tree.Roots)
+	}
+	if children := tree.Children(1); !reflect.DeepEqual(children, []uint32{3, 4}) {
+		t.Errorf("Expected children [3 4] but it was %v", children)
+	}
+	if children := tree.Children(6); len(children) != 0 {
+		t.Errorf("Expected no children but it was %v", children)
+	}
+	if descendants := tree.Descendants(0); !reflect.DeepEqual(descendants, []uint32{1, 3, 4, 6, 2, 5}) {
+		t.Errorf("Expected descendants [1 3 4 6 2 5] but it was %v", descendants)
+	}
+	if ancestors := tree.Ancestors(6); !reflect.DeepEqual(ancestors, []uint32{4, 1, 0}) {
+		t.Errorf("Expected ancestors [4 1 0] but it was %v", ancestors)
+	}
+	if ancestors := tree.Ancestors(7); len(ancestors) != 0 {
+		t.Errorf("Expected no ancestors but it was %v", ancestors)
+	}
+	if tree.Children(1234) != nil || tree.Descendants(1234) != nil || tree.Ancestors(1234) != nil {
+		t.Error("Expected nil for PID not in tree")
+	}
+
+	// Memory usage in the mock is 1024 + 1024 * PID
+	memoryUsage := tree.Node(4).TotalMemoryUsage()
+	if memoryUsage != (1024+1024*4)+(1024+1024*6) {
+		t.Errorf("Unexpected total memory usage %d", memoryUsage)
+	}
+}
+
+func TestBuildTreeExitedAndCycles(t *testing.T) {
+	pm := treeMock()
+	pm.Processes[2].DoExit = true
+	// Cycle 3 -> 5 -> 3 with 6 hanging off 5
+	pm.Processes[3].ParentPid = 5
+	pm.Processes[5].ParentPid = 3
+	pm.Processes[6].ParentPid = 5
+	snapshot, err := pm.Snapshot()
+	if err != nil {
+		t.Fatalf("Snapshot returned error: %s", err)
+	}
+	tree := BuildTree(snapshot)
+	if tree.Node(2) != nil {
+		t.Error("Expected exited process not to be in tree")
+	}
+	if ancestors := tree.Ancestors(6); !reflect.DeepEqual(ancestors, []uint32{5, 3}) {
+		t.Errorf("Expected ancestors [5 3] but it was %v", ancestors)
+	}
+	if tree.Node(3).Parent != nil {
+		t.Error("Expected process 3 to be a root when breaking the cycle")
+	}
+	var roots []uint32
+	for _, root := range tree.Roots {
+		roots = append(roots, root.Process.Pid)
+	}
+	if !reflect.DeepEqual(roots, []uint32{0, 3, 7}) {
+		t.Errorf("Expected roots [0 3 7] but it was %v", roots)
+	}
+}
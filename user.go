@@ -0,0 +1,46 @@
+package proci
+
+import (
+	"sort"
+)
+
+// UserMemoryUsage is the memory used by all processes of a user.
+type UserMemoryUsage struct {
+	UID         uint32
+	Username    string // Empty if unknown
+	MemoryUsage uint64 // Sum of the memory usage of the processes
+	Processes   int    // Number of processes
+}
+
+// MemoryUsageByUser sums the memory usage of the processes in a snapshot per
+// effective user. Processes whose user or memory usage could not be read are
+// not included. The result is sorted by memory usage, highest first.
+func MemoryUsageByUser(snapshot *SystemSnapshot) []UserMemoryUsage {
+	usages := make(map[uint32]*UserMemoryUsage)
+	for _, process := range snapshot.Processes {
+		if process.User == nil || process.UserErr != nil || process.MemoryUsageErr != nil {
+			continue
+		}
+		usage := usages[process.User.EffectiveUID]
+		if usage == nil {
+			usage = &UserMemoryUsage{
+				UID:      process.User.EffectiveUID,
+				Username: process.User.EffectiveUsername}
+			usages[usage.UID] = usage
+		}
+		usage.MemoryUsage += process.MemoryUsage
+		usage.Processes++
+	}
+
+	result := make([]UserMemoryUsage, 0, len(usages))
+	for _, usage := range usages {
+		result = append(result, *usage)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].MemoryUsage != result[j].MemoryUsage {
+			return result[i].MemoryUsage > result[j].MemoryUsage
+		}
+		return result[i].UID < result[j].UID
+	})
+	return result
+}
@@ -0,0 +1,36 @@
+// proci per user aggregation unit tests
+package proci
+
+import (
+	"testing"
+)
+
+func TestMemoryUsageByUser(t *testing.T) {
+	pm := GenerateMock(5)
+	alice := &ProcessUser{UID: 1000, EffectiveUID: 1000, EffectiveUsername: "alice"}
+	bob := &ProcessUser{UID: 1001, EffectiveUID: 1001, EffectiveUsername: "bob"}
+	pm.Processes[0].User = alice
+	pm.Processes[1].User = bob
+	pm.Processes[2].User = bob
+	pm.Processes[3].User = alice
+	pm.Processes[3].DoFailMemoryUsage = true
+	pm.Processes[4].DoFailUser = true
+
+	snapshot, err := pm.Snapshot()
+	if err != nil {
+		t.Fatalf("Snapshot returned error: %s", err)
+	}
+	usages := MemoryUsageByUser(snapshot)
+	if len(usages) != 2 {
+		t.Fatalf("Expected 2 users but it was %d", len(usages))
+	}
+	// Memory usage in the mock is 1024 + 1024 * PID
+	expected := []UserMemoryUsage{
+		{UID: 1001, Username: "bob", MemoryUsage: 2048 + 3072, Processes: 2},
+		{UID: 1000, Username: "alice", MemoryUsage: 1024, Processes: 1}}
+	for i := range expected {
+		if usages[i] != expected[i] {
+			t.Errorf("Expected %+v but it was %+v", expected[i], usages[i])
+		}
+	}
+}